@@ -63,6 +63,13 @@ type ConnString struct {
 	WTimeout                time.Duration
 
 	UsingSRV bool
+	// the hostname whose SRV record the hosts were looked up from
+	SRVHost string
+	// the ssl and replicaSet values came from the TXT record of the SRV
+	// host, rather than from the defaults or the query string, so options
+	// given explicitly elsewhere override them rather than conflict with them
+	UseSSLFromTXT     bool
+	ReplicaSetFromTXT bool
 
 	Options        map[string][]string
 	UnknownOptions map[string][]string
@@ -169,7 +176,8 @@ func (p *parser) parse(original string) error {
 		if len(parsedHosts) != 1 {
 			return fmt.Errorf("URI with SRV must include one and only one hostname")
 		}
-		parsedHosts, err = fetchSeedlistFromSRV(parsedHosts[0])
+		p.SRVHost = parsedHosts[0]
+		parsedHosts, err = FetchSeedlistFromSRV(p.SRVHost)
 		if err != nil {
 			return err
 		}
@@ -220,19 +228,61 @@ func (p *parser) parse(original string) error {
 	p.Database = extractedDatabase.db
 
 	connectionArgsFromQueryString, err := extractQueryArgsFromURI(uri)
-	connectionArgPairs := append(connectionArgsFromTXT, connectionArgsFromQueryString...)
+	if err != nil {
+		return err
+	}
 
-	for _, pair := range connectionArgPairs {
-		err = p.addOption(pair)
+	err = p.addOptions(connectionArgsFromTXT, connectionArgsFromQueryString)
+	if err != nil {
+		return err
+	}
+
+	if isSRV {
+		return validateSRVOptions(p.ConnString)
+	}
+
+	return nil
+}
+
+// addOptions adds the options from the TXT record of an SRV host and then
+// those from the query string, so that the TXT record overrides the SRV
+// defaults and the query string overrides both, noting which of the ssl and
+// replicaSet values are left from the TXT record.
+func (p *parser) addOptions(fromTXT, fromQueryString []string) error {
+	for _, pair := range fromTXT {
+		err := p.addOption(pair)
+		if err != nil {
+			return err
+		}
+	}
+	sslFromTXT := len(p.Options["ssl"])
+	replicaSetFromTXT := len(p.Options["replicaset"])
+
+	for _, pair := range fromQueryString {
+		err := p.addOption(pair)
 		if err != nil {
 			return err
 		}
 	}
+	p.UseSSLFromTXT = sslFromTXT > 0 && len(p.Options["ssl"]) == sslFromTXT
+	p.ReplicaSetFromTXT = replicaSetFromTXT > 0 && len(p.Options["replicaset"]) == replicaSetFromTXT
+	return nil
+}
 
+// validateSRVOptions checks the options of a mongodb+srv URI once all of them
+// have been parsed. Which hosts the seed list holds is up to the SRV record,
+// and a direct connection only ever talks to the first of them, so the two
+// can't be combined.
+func validateSRVOptions(cs ConnString) error {
+	if cs.Connect == SingleConnect {
+		return fmt.Errorf("URI with SRV cannot use a direct connection")
+	}
 	return nil
 }
 
-func fetchSeedlistFromSRV(host string) ([]string, error) {
+// FetchSeedlistFromSRV looks up the SRV record of host and returns the hosts
+// it lists, each of which must be in the same domain as host.
+func FetchSeedlistFromSRV(host string) ([]string, error) {
 	var err error
 
 	_, _, err = net.SplitHostPort(host)
@@ -490,6 +540,7 @@ func validateSRVResult(recordFromSRV, inputHostName string) error {
 var allowedTXTOptions = map[string]struct{}{
 	"authsource": {},
 	"replicaset": {},
+	"ssl":        {},
 }
 
 func validateTXTResult(paramsFromTXT []string) error {
@@ -0,0 +1,73 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+package connstring
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"testing"
+)
+
+func TestAddOptionsFromTXT(t *testing.T) {
+	Convey("With options from a TXT record and the query string", t, func() {
+		testCases := []struct {
+			Name              string
+			FromTXT           []string
+			FromQueryString   []string
+			ReplicaSet        string
+			ReplicaSetFromTXT bool
+			UseSSL            bool
+			UseSSLFromTXT     bool
+		}{
+			{
+				Name:              "the TXT record sets defaults",
+				FromTXT:           []string{"replicaSet=fromtxt", "ssl=false"},
+				ReplicaSet:        "fromtxt",
+				ReplicaSetFromTXT: true,
+				UseSSL:            false,
+				UseSSLFromTXT:     true,
+			},
+			{
+				Name:            "the query string overrides the TXT record",
+				FromTXT:         []string{"replicaSet=fromtxt", "ssl=false"},
+				FromQueryString: []string{"replicaSet=fromquery", "ssl=true"},
+				ReplicaSet:      "fromquery",
+				UseSSL:          true,
+			},
+			{
+				Name:              "the query string only overrides what it sets",
+				FromTXT:           []string{"replicaSet=fromtxt", "ssl=false"},
+				FromQueryString:   []string{"ssl=true"},
+				ReplicaSet:        "fromtxt",
+				ReplicaSetFromTXT: true,
+				UseSSL:            true,
+			},
+			{
+				Name:            "without a TXT record",
+				FromQueryString: []string{"replicaSet=fromquery"},
+				ReplicaSet:      "fromquery",
+			},
+		}
+
+		for _, testCase := range testCases {
+			t.Log("Test Case:", testCase.Name)
+			p := &parser{}
+			So(p.addOptions(testCase.FromTXT, testCase.FromQueryString), ShouldBeNil)
+			So(p.ReplicaSet, ShouldEqual, testCase.ReplicaSet)
+			So(p.ReplicaSetFromTXT, ShouldEqual, testCase.ReplicaSetFromTXT)
+			So(p.UseSSL, ShouldEqual, testCase.UseSSL)
+			So(p.UseSSLFromTXT, ShouldEqual, testCase.UseSSLFromTXT)
+		}
+	})
+}
+
+func TestValidateSRVOptions(t *testing.T) {
+	Convey("With the options of an SRV URI", t, func() {
+		So(validateSRVOptions(ConnString{Connect: AutoConnect}), ShouldBeNil)
+		So(validateSRVOptions(ConnString{Connect: SingleConnect}), ShouldNotBeNil)
+	})
+}
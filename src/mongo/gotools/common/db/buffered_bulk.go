@@ -8,6 +8,8 @@ package db
 
 import (
 	"fmt"
+	"time"
+
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -24,6 +26,8 @@ type BufferedBulkInserter struct {
 	byteCount       int
 	docCount        int
 	unordered       bool
+	// how long to wait for a new primary if one steps down, if retrying
+	stepDownTimeout time.Duration
 }
 
 // NewBufferedBulkInserter returns an initialized BufferedBulkInserter
@@ -44,6 +48,20 @@ func (bb *BufferedBulkInserter) Unordered() {
 	bb.bulk.Unordered()
 }
 
+// RetryOnStepDown makes each bulk insert wait up to timeout for a new primary
+// and run again if the primary steps down during it. Documents the old
+// primary already inserted are inserted again, so this is only useful when
+// continuing on errors such as duplicate keys. An ordered bulk insert would
+// stop at the first of them instead, so retrying one is refused.
+func (bb *BufferedBulkInserter) RetryOnStepDown(timeout time.Duration) error {
+	if !bb.continueOnError && !bb.unordered {
+		return fmt.Errorf("can't retry an ordered bulk insert after a step down, " +
+			"since it would stop at the first document the old primary already inserted")
+	}
+	bb.stepDownTimeout = timeout
+	return nil
+}
+
 // throw away the old bulk and init a new one
 func (bb *BufferedBulkInserter) resetBulk() {
 	bb.bulk = bb.collection.Bulk()
@@ -78,8 +96,12 @@ func (bb *BufferedBulkInserter) Flush() error {
 		return nil
 	}
 	defer bb.resetBulk()
-	if _, err := bb.bulk.Run(); err != nil {
+	run := func() error {
+		_, err := bb.bulk.Run()
 		return err
 	}
-	return nil
+	if bb.stepDownTimeout > 0 {
+		return RetryOnStepDown(bb.collection.Database.Session, bb.stepDownTimeout, run)
+	}
+	return run()
 }
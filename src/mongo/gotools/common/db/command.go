@@ -113,6 +113,31 @@ func (sp *SessionProvider) GetNodeType() (NodeType, error) {
 	return Standalone, nil
 }
 
+// checkNotArbiter returns ErrConnectedToArbiter if session is connected to an
+// arbiter, which only a direct connection can be. isMaster is run on a copy
+// of session that's allowed to use non-primaries, since in the default strong
+// mode a direct connection to a secondary or an arbiter can't run anything.
+func checkNotArbiter(session *mgo.Session) error {
+	probe := session.Copy()
+	defer probe.Close()
+	probe.SetMode(mgo.Monotonic, true)
+
+	masterDoc := bson.M{}
+	if err := probe.Run("isMaster", &masterDoc); err != nil {
+		return fmt.Errorf("error checking whether the server is an arbiter: %v", err)
+	}
+	return arbiterCheck(masterDoc)
+}
+
+// arbiterCheck returns ErrConnectedToArbiter if masterDoc, the result of
+// isMaster, comes from an arbiter.
+func arbiterCheck(masterDoc bson.M) error {
+	if arbiterOnly, _ := masterDoc["arbiterOnly"].(bool); arbiterOnly {
+		return ErrConnectedToArbiter
+	}
+	return nil
+}
+
 // IsReplicaSet returns a boolean which is true if the connected server is part
 // of a replica set.
 func (sp *SessionProvider) IsReplicaSet() (bool, error) {
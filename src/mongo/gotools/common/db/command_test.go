@@ -0,0 +1,34 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+package db
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestArbiterCheck(t *testing.T) {
+	Convey("When checking the isMaster result of a direct connection", t, func() {
+		Convey("a primary should be accepted", func() {
+			So(arbiterCheck(bson.M{"ismaster": true, "setName": "rs0"}), ShouldBeNil)
+		})
+
+		Convey("a secondary should be accepted", func() {
+			So(arbiterCheck(bson.M{"ismaster": false, "secondary": true, "setName": "rs0"}), ShouldBeNil)
+		})
+
+		Convey("a standalone should be accepted", func() {
+			So(arbiterCheck(bson.M{"ismaster": true}), ShouldBeNil)
+		})
+
+		Convey("an arbiter should be rejected", func() {
+			So(arbiterCheck(bson.M{"ismaster": false, "arbiterOnly": true, "setName": "rs0"}), ShouldEqual, ErrConnectedToArbiter)
+		})
+	})
+}
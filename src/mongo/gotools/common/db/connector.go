@@ -7,6 +7,7 @@
 package db
 
 import (
+	"fmt"
 	"net"
 	"time"
 
@@ -34,6 +35,13 @@ type VanillaDBConnector struct {
 // connection string and then sets up the dial information using the default
 // dial timeout.
 func (self *VanillaDBConnector) Configure(opts options.ToolOptions) error {
+	if opts.Auth.Mechanism == "MONGODB-AWS" {
+		return fmt.Errorf("MONGODB-AWS authentication is not supported by this connector")
+	}
+	if err := opts.ValidateDriverOptions(); err != nil {
+		return err
+	}
+
 	timeout := time.Duration(opts.Timeout) * time.Second
 
 	// create the dialer func that will be used to connect
@@ -69,9 +77,10 @@ func (self *VanillaDBConnector) Configure(opts options.ToolOptions) error {
 		self.dialInfo.Addrs = util.CreateConnectionAddrs(opts.Host, opts.Port)
 	}
 
-	kerberos.AddKerberosOpts(opts, self.dialInfo)
-
-	return nil
+	if err := opts.Auth.ValidateMechanismProperties(); err != nil {
+		return err
+	}
+	return kerberos.AddKerberosOpts(opts, self.dialInfo)
 }
 
 // GetNewSession connects to the server and returns the established session and any
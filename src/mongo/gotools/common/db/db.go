@@ -14,6 +14,7 @@ import (
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -60,6 +61,10 @@ const (
 
 var (
 	GetConnectorFuncs = []GetConnectorFunc{}
+
+	// ErrConnectedToArbiter is returned by GetSession when arbiters are
+	// rejected and the connected node is one.
+	ErrConnectedToArbiter = errors.New("connected to an arbiter; no data available")
 )
 
 // Used to manage database sessions
@@ -79,6 +84,9 @@ type SessionProvider struct {
 	flags                    sessionFlag
 	readPreference           mgo.Mode
 	tags                     bson.D
+
+	// whether to fail if the master session is connected to an arbiter
+	rejectArbiter bool
 }
 
 // ApplyOpsResponse represents the response from an 'applyOps' command.
@@ -116,6 +124,13 @@ func (self *SessionProvider) GetSession() (*mgo.Session, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to db server: %v", err)
 	}
+	if self.rejectArbiter {
+		if err = checkNotArbiter(self.masterSession); err != nil {
+			self.masterSession.Close()
+			self.masterSession = nil
+			return nil, err
+		}
+	}
 
 	// update masterSession based on flags
 	self.refresh()
@@ -131,6 +146,12 @@ func (self *SessionProvider) Close() {
 	if self.masterSession != nil {
 		self.masterSession.Close()
 	}
+	// release anything the connector holds on to between sessions
+	if closer, ok := self.connector.(interface {
+		Close()
+	}); ok {
+		closer.Close()
+	}
 }
 
 // refresh is a helper for modifying the session based on the
@@ -140,8 +161,15 @@ func (self *SessionProvider) refresh() {
 	// handle bypassDocumentValidation
 	self.masterSession.SetBypassValidation(self.bypassDocumentValidation)
 
-	// handle readPreference
-	self.masterSession.SetMode(self.readPreference, true)
+	// handle readPreference. The sessions of a connector that connects each
+	// one directly to a single node, which may be a secondary, can't run
+	// anything in primary mode, and since they know no other node, a mode
+	// that allows secondaries can't send reads anywhere else.
+	mode := self.readPreference
+	if mode == mgo.Primary && self.directSessions() {
+		mode = mgo.Monotonic
+	}
+	self.masterSession.SetMode(mode, true)
 
 	// disable timeouts
 	if (self.flags & DisableSocketTimeout) > 0 {
@@ -152,6 +180,15 @@ func (self *SessionProvider) refresh() {
 	}
 }
 
+// directSessions reports whether the connector connects each session
+// directly to a single node.
+func (self *SessionProvider) directSessions() bool {
+	direct, ok := self.connector.(interface {
+		DirectSessions() bool
+	})
+	return ok && direct.DirectSessions()
+}
+
 // SetFlags allows certain modifications to the masterSession after initial creation.
 func (self *SessionProvider) SetFlags(flagBits sessionFlag) {
 	self.masterSessionLock.Lock()
@@ -211,6 +248,7 @@ func NewSessionProvider(opts options.ToolOptions) (*SessionProvider, error) {
 	provider := &SessionProvider{
 		readPreference:           mgo.Primary,
 		bypassDocumentValidation: false,
+		rejectArbiter:            opts.RejectArbiter,
 	}
 
 	// finalize auth options, filling in missing passwords
@@ -11,24 +11,46 @@ package kerberos
 // #cgo windows LDFLAGS: -Lc:/sasl/lib
 
 import (
+	"fmt"
+
 	"github.com/mongodb/mongo-tools/common/options"
 	"gopkg.in/mgo.v2"
 )
 
 const authMechanism = "GSSAPI"
 
-func AddKerberosOpts(opts options.ToolOptions, dialInfo *mgo.DialInfo) {
+func AddKerberosOpts(opts options.ToolOptions, dialInfo *mgo.DialInfo) error {
 	if dialInfo == nil {
-		return
+		return nil
 	}
 	if opts.Kerberos == nil {
-		return
+		return nil
 	}
 	if opts.Auth == nil || (opts.Auth.Mechanism != authMechanism &&
 		dialInfo.Mechanism != authMechanism) {
-		return
+		return nil
 	}
 	dialInfo.Service = opts.Kerberos.Service
 	dialInfo.ServiceHost = opts.Kerberos.ServiceHost
 	dialInfo.Mechanism = authMechanism
+
+	// the service name and host can also be given as mechanism properties,
+	// which are the only ones Auth.ValidateMechanismProperties accepts
+	for name, value := range opts.Auth.MechanismProperties {
+		switch name {
+		case "SERVICE_NAME":
+			if dialInfo.Service != "" && dialInfo.Service != value {
+				return fmt.Errorf("mechanism property SERVICE_NAME conflicts with --gssapiServiceName")
+			}
+			dialInfo.Service = value
+		case "SERVICE_HOST":
+			if dialInfo.ServiceHost != "" && dialInfo.ServiceHost != value {
+				return fmt.Errorf("mechanism property SERVICE_HOST conflicts with --gssapiHostName")
+			}
+			dialInfo.ServiceHost = value
+		default:
+			return fmt.Errorf("mechanism property %v is not supported by this connector", name)
+		}
+	}
+	return nil
 }
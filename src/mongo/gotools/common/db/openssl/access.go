@@ -0,0 +1,113 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/mongo-tools/common/util"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AccessError is returned by CheckAccess when some namespaces can't be
+// accessed. Failures holds why for each of them, keyed by namespace; errors
+// caused by missing privileges are *AuthErrors.
+type AccessError struct {
+	Failures map[string]error
+}
+
+func (e *AccessError) Error() string {
+	namespaces := make([]string, 0, len(e.Failures))
+	for namespace := range e.Failures {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	messages := make([]string, len(namespaces))
+	for i, namespace := range namespaces {
+		messages[i] = fmt.Sprintf("%v: %v", namespace, e.Failures[namespace])
+	}
+	return fmt.Sprintf("can't access namespaces: %v", strings.Join(messages, "; "))
+}
+
+// CheckAccess connects to the server and checks that the connector's
+// credentials allow reading each of namespaces, so that a long job can find
+// out about missing privileges before it starts. A database must allow
+// listing its collections and reading each of them, except system
+// collections; a collection must exist and allow reading. The server may be
+// any member, including a secondary connected to directly. It returns an
+// *AccessError describing every namespace that failed, or an error if no
+// session could be established, passed through the error formatter if one is
+// set. It must be called after Configure.
+func (self *SSLDBConnector) CheckAccess(namespaces []string) error {
+	session, err := self.GetNewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	// listing and reading collections is allowed on secondaries
+	session.SetMode(mgo.Monotonic, true)
+
+	failures := map[string]error{}
+	for _, namespace := range namespaces {
+		if err := checkNamespaceAccess(session, namespace); err != nil {
+			if authErr := newAuthError(err, self.dialInfo.Mechanism, self.dialInfo.Source); authErr != nil {
+				err = authErr
+			}
+			failures[namespace] = err
+		}
+	}
+	if len(failures) > 0 {
+		return self.phaseError(phaseCommand, &AccessError{Failures: failures})
+	}
+	return nil
+}
+
+// checkNamespaceAccess checks that namespace, a database or a collection,
+// can be read on session.
+func checkNamespaceAccess(session *mgo.Session, namespace string) error {
+	database, collection := util.SplitNamespace(namespace)
+	if database == "" {
+		return fmt.Errorf("no database named")
+	}
+	names, err := session.DB(database).CollectionNames()
+	if err != nil {
+		return fmt.Errorf("error listing collections: %v", err)
+	}
+	if collection != "" {
+		for _, name := range names {
+			if name == collection {
+				return checkCollectionRead(session.DB(database).C(collection))
+			}
+		}
+		return fmt.Errorf("collection does not exist")
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, "system.") {
+			continue
+		}
+		if err = checkCollectionRead(session.DB(database).C(name)); err != nil {
+			return fmt.Errorf("collection %v: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// checkCollectionRead reads a document from collection, which succeeds even
+// if it's empty as long as the query is allowed.
+func checkCollectionRead(collection *mgo.Collection) error {
+	var doc bson.Raw
+	err := collection.Find(nil).Limit(1).One(&doc)
+	if err != nil && err != mgo.ErrNotFound {
+		return fmt.Errorf("error reading: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// accessReply answers as a mongod whose databases hold the given
+// collections, refusing to read the collections in denied.
+func accessReply(databases map[string][]string, denied map[string]bool) wireReply {
+	return func(database string, command bson.D) bson.M {
+		switch command[0].Name {
+		case "ismaster", "isMaster":
+			// new enough for finds to be sent as commands
+			return bson.M{"ismaster": true, "maxWireVersion": 4, "ok": 1}
+		case "listCollections":
+			var batch []bson.M
+			for _, name := range databases[database] {
+				batch = append(batch, bson.M{"name": name})
+			}
+			return bson.M{"cursor": bson.M{"firstBatch": batch, "id": int64(0), "ns": database + ".$cmd.listCollections"}, "ok": 1}
+		case "find":
+			namespace := fmt.Sprintf("%v.%v", database, command[0].Value)
+			if denied[namespace] {
+				return bson.M{"ok": 0, "code": 13, "errmsg": fmt.Sprintf("not authorized on %v to execute command { find: %q }", database, command[0].Value)}
+			}
+			return bson.M{"cursor": bson.M{"firstBatch": []bson.M{}, "id": int64(0), "ns": namespace}, "ok": 1}
+		}
+		return nil
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	dir, cleanup := testDir(t, "access")
+	defer cleanup()
+
+	ca := newTestCA(t, "Access Test CA")
+	server := newServerCert(t, "server", ca)
+	address, closeServer := tlsWireServer(t, server, accessReply(
+		map[string][]string{
+			"app":     {"users", "orders", "system.views"},
+			"private": {"public", "secret"},
+		},
+		map[string]bool{"private.secret": true, "app.system.views": true},
+	))
+	defer closeServer()
+
+	connector := localConnector(t, address, pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.Timeout = 5
+	})
+	defer connector.Close()
+
+	// system collections aren't read when checking a whole database
+	if err := connector.CheckAccess([]string{"app", "app.users", "private.public"}); err != nil {
+		t.Errorf("Unexpected error checking readable namespaces: %v", err)
+	}
+
+	err := connector.CheckAccess([]string{"app.orders", "app.missing", "private", "private.secret"})
+	accessErr, ok := err.(*AccessError)
+	if !ok {
+		t.Fatalf("Expected an *AccessError, got %T: %v", err, err)
+	}
+	if len(accessErr.Failures) != 3 {
+		t.Errorf("Expected 3 namespaces to fail: %v", accessErr)
+	}
+	if _, ok := accessErr.Failures["app.missing"]; !ok {
+		t.Errorf("Expected a missing collection to fail: %v", accessErr)
+	}
+	for _, namespace := range []string{"private", "private.secret"} {
+		authErr, ok := accessErr.Failures[namespace].(*AuthError)
+		if !ok {
+			t.Errorf("%v: expected an *AuthError, got %T: %v", namespace, accessErr.Failures[namespace], accessErr.Failures[namespace])
+		} else if authErr.Reason != AuthFailureUnauthorized {
+			t.Errorf("%v: reason is %v, expected %v", namespace, authErr.Reason, AuthFailureUnauthorized)
+		}
+	}
+}
+
+func TestCheckAccessSecondary(t *testing.T) {
+	dir, cleanup := testDir(t, "access")
+	defer cleanup()
+
+	ca := newTestCA(t, "Access Test CA")
+	address, closeServer := tlsWireServer(t, newServerCert(t, "server", ca), secondaryReply(accessReply(
+		map[string][]string{"app": {"users"}},
+		map[string]bool{},
+	)))
+	defer closeServer()
+
+	connector := localConnector(t, address, pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.Timeout = 1
+		opts.Direct = true
+	})
+	defer connector.Close()
+
+	if err := connector.CheckAccess([]string{"app", "app.users"}); err != nil {
+		t.Errorf("Unexpected error checking namespaces on a secondary: %v", err)
+	}
+}
+
+func TestCheckAccessNoSession(t *testing.T) {
+	connector := localConnector(t, closedAddr(t), "testdata/ca.pem", func(opts *options.ToolOptions) {
+		opts.Timeout = 1
+	})
+	defer connector.Close()
+	err := connector.CheckAccess([]string{"app"})
+	if err == nil {
+		t.Fatalf("Expected an error without a server")
+	}
+	if _, ok := err.(*AccessError); ok {
+		t.Errorf("Expected the session's error, not an *AccessError: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// connAddrs holds the local and remote addresses of a connection. The zero
+// value holds no addresses.
+type connAddrs struct {
+	mu            sync.Mutex
+	local, remote net.Addr
+}
+
+func (a *connAddrs) set(local, remote net.Addr) {
+	a.mu.Lock()
+	a.local, a.remote = local, remote
+	a.mu.Unlock()
+}
+
+// ConnAddrs returns the local and remote addresses of the most recent
+// successful connection to a server, or an error if there hasn't been one.
+func (self *SSLDBConnector) ConnAddrs() (local, remote net.Addr, err error) {
+	self.lastConn.mu.Lock()
+	defer self.lastConn.mu.Unlock()
+	if self.lastConn.remote == nil {
+		return nil, nil, self.phaseError(phaseSession, fmt.Errorf("no connection to a server has been made"))
+	}
+	return self.lastConn.local, self.lastConn.remote, nil
+}
@@ -0,0 +1,53 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+)
+
+func TestConnAddrs(t *testing.T) {
+	dir, cleanup := testDir(t, "addrs")
+	defer cleanup()
+
+	ca := newTestCA(t, "Addrs Test CA")
+	good, closeGood := tlsServer(t, newServerCert(t, "good", ca))
+	defer closeGood()
+
+	connector := localConnector(t, good, pemFile(t, dir, "ca.pem", ca), nil)
+	defer connector.Close()
+
+	if _, _, err := connector.ConnAddrs(); err == nil {
+		t.Errorf("Expected an error before any connection was made")
+	}
+
+	conn, err := connector.dial(good)
+	if err != nil {
+		t.Fatalf("Error dialing: %v", err)
+	}
+	local, remote := conn.LocalAddr().String(), conn.RemoteAddr().String()
+	conn.Close()
+
+	// a failed connection doesn't replace the addresses of the last good one
+	if conn, err := connector.dial(closedAddr(t)); err == nil {
+		conn.Close()
+		t.Errorf("Expected an error dialing a closed port")
+	}
+
+	gotLocal, gotRemote, err := connector.ConnAddrs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotLocal.String() != local || gotRemote.String() != remote {
+		t.Errorf("Addresses are %v -> %v, expected %v -> %v", gotLocal, gotRemote, local, remote)
+	}
+	if gotRemote.String() != good {
+		t.Errorf("Remote address is %v, expected %v", gotRemote, good)
+	}
+}
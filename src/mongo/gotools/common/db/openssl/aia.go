@@ -0,0 +1,176 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+)
+
+// maxIntermediateSize limits how much of a response is read when fetching an
+// intermediate certificate.
+const maxIntermediateSize = 1 << 20
+
+// intermediateRetryInterval is how long to wait before fetching from a URL
+// again after it failed, so that an outage of the URL doesn't stop the
+// intermediate being fetched for good, but every connection doesn't retry it.
+const intermediateRetryInterval = time.Minute
+
+// intermediateFetcher adds intermediates missing from a server's chain to the
+// ctx's certificate store, fetching them from the Authority Information
+// Access caIssuers URLs of the certificates the server did send. Fetched
+// intermediates are only used to build the chain; it must still end at a
+// trusted root.
+type intermediateFetcher struct {
+	store   *openssl.CertificateStore
+	timeout time.Duration
+
+	retryInterval time.Duration
+
+	mu sync.Mutex
+	// the URLs whose certificates have been added to the store, which are
+	// never fetched again
+	fetched map[string]bool
+	// when each URL that failed, or is being fetched, may be fetched again
+	retryAt map[string]time.Time
+}
+
+func newIntermediateFetcher(ctx *openssl.Ctx, timeout time.Duration) *intermediateFetcher {
+	return &intermediateFetcher{
+		store:         ctx.GetCertificateStore(),
+		timeout:       timeout,
+		retryInterval: intermediateRetryInterval,
+		fetched:       map[string]bool{},
+		retryAt:       map[string]time.Time{},
+	}
+}
+
+// fetchMissing returns true if the handshake on conn failed because an issuer
+// wasn't found and at least one intermediate that wasn't available before has
+// been added to the store, in which case connecting again may succeed.
+func (f *intermediateFetcher) fetchMissing(conn *openssl.Conn) bool {
+	switch conn.VerifyResult() {
+	case openssl.UnableToGetIssuerCertLocally, openssl.UnableToGetIssuerCert:
+	default:
+		return false
+	}
+	chain, err := conn.PeerCertificateChain()
+	if err != nil {
+		return false
+	}
+	parsed := make([]*x509.Certificate, 0, len(chain))
+	for _, cert := range chain {
+		x509Cert, err := toX509(cert)
+		if err != nil {
+			return false
+		}
+		parsed = append(parsed, x509Cert)
+	}
+
+	added := false
+	for _, cert := range topOfChain(parsed) {
+		for _, url := range cert.IssuingCertificateURL {
+			if f.add(url, cert) {
+				added = true
+			}
+		}
+	}
+	return added
+}
+
+// add fetches the issuer of cert from url and adds it to the store, unless
+// it was already added or fetching it failed within the retry interval, and
+// returns whether it was added.
+func (f *intermediateFetcher) add(url string, cert *x509.Certificate) bool {
+	now := time.Now()
+	f.mu.Lock()
+	if f.fetched[url] || now.Before(f.retryAt[url]) {
+		f.mu.Unlock()
+		return false
+	}
+	// other connections leave it alone while it's fetched, and until the
+	// retry interval passes if it fails
+	f.retryAt[url] = now.Add(f.retryInterval)
+	f.mu.Unlock()
+
+	intermediate, err := f.fetch(url)
+	if err != nil {
+		log.Logvf(log.Always, "error fetching the issuer of %v from %v: %v", cert.Subject, url, err)
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err = f.store.AddCertificate(intermediate); err != nil {
+		log.Logvf(log.Always, "error adding the issuer of %v fetched from %v: %v", cert.Subject, url, err)
+		return false
+	}
+	f.fetched[url] = true
+	delete(f.retryAt, url)
+	log.Logvf(log.Info, "fetched the missing issuer of %v from %v", cert.Subject, url)
+	return true
+}
+
+// topOfChain returns the certificates in chain that aren't issued by another
+// certificate in it, which are the ones whose issuers are missing.
+func topOfChain(chain []*x509.Certificate) []*x509.Certificate {
+	var top []*x509.Certificate
+	for _, cert := range chain {
+		issued := false
+		for _, other := range chain {
+			if other != cert && bytes.Equal(cert.RawIssuer, other.RawSubject) {
+				issued = true
+				break
+			}
+		}
+		if !issued {
+			top = append(top, cert)
+		}
+	}
+	return top
+}
+
+// fetch downloads the DER or PEM encoded certificate at url.
+func (f *intermediateFetcher) fetch(url string) (*openssl.Certificate, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("unsupported URL scheme")
+	}
+	client := http.Client{Timeout: f.timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %v", resp.Status)
+	}
+	data, err := ioutil.ReadAll(&io.LimitedReader{R: resp.Body, N: maxIntermediateSize})
+	if err != nil {
+		return nil, err
+	}
+
+	der := data
+	if block, _ := pem.Decode(data); block != nil {
+		der = block.Bytes
+	}
+	if _, err = x509.ParseCertificate(der); err != nil {
+		return nil, fmt.Errorf("error parsing certificate: %v", err)
+	}
+	return openssl.LoadCertificateFromPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
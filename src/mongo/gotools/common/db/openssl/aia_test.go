@@ -0,0 +1,77 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/10gen/openssl"
+)
+
+func TestIntermediateFetcherCache(t *testing.T) {
+	ca := newTestCA(t, "AIA Test CA")
+	intermediate := newTestIntermediate(t, "AIA Test Intermediate", ca)
+	server := newTestCert(t, "server", x509.Certificate{}, intermediate)
+
+	var mu sync.Mutex
+	requests, failing := 0, true
+	aia := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(intermediate.cert.Raw)
+	}))
+	defer aia.Close()
+
+	ctx, err := openssl.NewCtx()
+	if err != nil {
+		t.Fatalf("Error creating ctx: %v", err)
+	}
+	fetcher := newIntermediateFetcher(ctx, time.Second)
+	fetcher.retryInterval = 100 * time.Millisecond
+
+	steps := []struct {
+		Name     string
+		Fix      bool
+		Wait     time.Duration
+		Added    bool
+		Requests int
+	}{
+		{Name: "failed fetch", Added: false, Requests: 1},
+		{Name: "within the retry interval", Fix: true, Added: false, Requests: 1},
+		{Name: "after the retry interval", Wait: 150 * time.Millisecond, Added: true, Requests: 2},
+		{Name: "already added", Added: false, Requests: 2},
+	}
+
+	for _, v := range steps {
+		if v.Fix {
+			mu.Lock()
+			failing = false
+			mu.Unlock()
+		}
+		time.Sleep(v.Wait)
+		if added := fetcher.add(aia.URL, server.cert); added != v.Added {
+			t.Errorf("%v: added is %v, expected %v", v.Name, added, v.Added)
+		}
+		mu.Lock()
+		if requests != v.Requests {
+			t.Errorf("%v: %v requests made, expected %v", v.Name, requests, v.Requests)
+		}
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/10gen/openssl"
+)
+
+// keyIdentifiedCAs returns the certificates in the CA file, each of which
+// must have a subject key identifier for the authority key identifiers of
+// the certificates it issues to be checked against.
+func keyIdentifiedCAs(caFile string) ([]*x509.Certificate, error) {
+	cas, err := caFileCertificates(caFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, ca := range cas {
+		if len(ca.SubjectKeyId) == 0 {
+			return nil, fmt.Errorf("CA certificate %v has no subject key identifier, which --sslVerifyAKI requires", ca.Subject)
+		}
+	}
+	return cas, nil
+}
+
+// caFileCertificates returns every certificate in the CA file.
+func caFileCertificates(caFile string) ([]*x509.Certificate, error) {
+	pemBytes, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA file: %v", err)
+	}
+	cas, err := parseChain(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA file: %v", err)
+	}
+	return cas, nil
+}
+
+// checkAuthorityKeyIDs returns an error unless the chain presented on conn
+// links up to one of cas by key identifier: the authority key identifier of
+// each certificate, starting with the server's, must be the subject key
+// identifier of the next certificate the server sent, until it is that of
+// the trusted CA that issued it. A CA that merely shares a trusted CA's name
+// uses a different key, so the certificates it issues don't match.
+func checkAuthorityKeyIDs(conn *openssl.Conn, cas []*x509.Certificate) error {
+	chain, err := conn.PeerCertificateChain()
+	if err != nil {
+		return fmt.Errorf("error getting the server's certificate chain: %v", err)
+	}
+	for i, cert := range chain {
+		parsed, err := toX509(cert)
+		if err != nil {
+			return fmt.Errorf("error parsing the server's certificate chain: %v", err)
+		}
+		if len(parsed.AuthorityKeyId) == 0 {
+			return fmt.Errorf("certificate %v has no authority key identifier", parsed.Subject)
+		}
+		for _, ca := range cas {
+			if bytes.Equal(parsed.RawIssuer, ca.RawSubject) && bytes.Equal(parsed.AuthorityKeyId, ca.SubjectKeyId) {
+				return nil
+			}
+		}
+		if i+1 == len(chain) {
+			break
+		}
+		next, err := toX509(chain[i+1])
+		if err != nil {
+			return fmt.Errorf("error parsing the server's certificate chain: %v", err)
+		}
+		if !bytes.Equal(parsed.AuthorityKeyId, next.SubjectKeyId) {
+			return fmt.Errorf("certificate %v has authority key identifier %x, which doesn't identify the key of the next certificate in the chain, %v",
+				parsed.Subject, parsed.AuthorityKeyId, next.Subject)
+		}
+	}
+	return fmt.Errorf("the server's certificate chain doesn't link to a trusted CA by key identifier")
+}
@@ -0,0 +1,86 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestKeyIdentifiedCAs(t *testing.T) {
+	dir, cleanup := testDir(t, "aki")
+	defer cleanup()
+
+	ca := newTestCA(t, "AKI Test CA")
+	// only CA certificates get a generated subject key identifier
+	unidentified := newTestCert(t, "Unidentified CA", x509.Certificate{}, nil)
+
+	cases := []struct {
+		Name   string
+		CAFile string
+		Valid  bool
+	}{
+		{Name: "identified CA", CAFile: pemFile(t, dir, "ca.pem", ca), Valid: true},
+		{Name: "CA without a subject key identifier", CAFile: pemFile(t, dir, "unidentified.pem", ca, unidentified)},
+		{Name: "missing CA file", CAFile: filepath.Join(dir, "missing.pem")},
+	}
+
+	for _, v := range cases {
+		if _, err := keyIdentifiedCAs(v.CAFile); (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+	}
+}
+
+func TestVerifyAKI(t *testing.T) {
+	dir, cleanup := testDir(t, "aki")
+	defer cleanup()
+
+	ca := newTestCA(t, "AKI Test CA")
+	intermediate := newTestIntermediate(t, "AKI Intermediate CA", ca)
+	direct, closeDirect := tlsServer(t, newServerCert(t, "direct", ca))
+	defer closeDirect()
+	chained, closeChained := tlsServer(t, newServerCert(t, "chained", intermediate), intermediate)
+	defer closeChained()
+
+	connector := localConnector(t, direct, pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.SSLVerifyAKI = true
+	})
+	defer connector.Close()
+
+	for _, address := range []string{direct, chained} {
+		if conn, err := connector.dial(address); err != nil {
+			t.Errorf("Error dialing %v: %v", address, err)
+		} else {
+			conn.Close()
+		}
+	}
+}
+
+func TestVerifyAKIOptions(t *testing.T) {
+	cases := []struct {
+		Name string
+		SSL  options.SSL
+	}{
+		{Name: "no CA file", SSL: options.SSL{UseSSL: true, SSLVerifyAKI: true}},
+		{Name: "invalid certificates allowed", SSL: options.SSL{UseSSL: true, SSLCAFile: "testdata/ca.pem", SSLVerifyAKI: true, SSLAllowInvalidCert: true}},
+	}
+
+	for _, v := range cases {
+		ssl := v.SSL
+		opts := testOptions("localhost")
+		opts.SSL = &ssl
+		if err := (&SSLDBConnector{}).Configure(opts); err == nil {
+			t.Errorf("%v: expected an error", v.Name)
+		}
+	}
+}
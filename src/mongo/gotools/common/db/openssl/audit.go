@@ -0,0 +1,88 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/10gen/openssl"
+)
+
+// auditRecord is a single line of the SSL audit file. It describes the
+// secure channel of one successful connection and never contains secrets.
+type auditRecord struct {
+	Timestamp    time.Time  `json:"timestamp"`
+	Host         string     `json:"host"`
+	Protocol     string     `json:"protocol,omitempty"`
+	Cipher       string     `json:"cipher"`
+	PeerSubject  string     `json:"peerSubject,omitempty"`
+	PeerIssuer   string     `json:"peerIssuer,omitempty"`
+	PeerSerial   string     `json:"peerSerial,omitempty"`
+	PeerNotAfter *time.Time `json:"peerNotAfter,omitempty"`
+	Verification string     `json:"verification"`
+}
+
+// auditLog appends one JSON document per connection to a file.
+type auditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// newAuditRecord describes the negotiated session on conn to host.
+func newAuditRecord(host string, conn *openssl.Conn) auditRecord {
+	record := auditRecord{
+		Timestamp:    time.Now().UTC(),
+		Host:         host,
+		Verification: "ok",
+	}
+	if cipher, err := conn.CurrentCipher(); err == nil {
+		record.Cipher = cipher
+	}
+	if recorder, ok := conn.UnderlyingConn().(*helloRecorder); ok {
+		if negotiated, err := recorder.extensions(); err == nil {
+			record.Protocol = negotiated.Version
+		}
+	}
+	if cert, err := peerCertificate(conn); err == nil {
+		record.PeerSubject = cert.Subject.String()
+		record.PeerIssuer = cert.Issuer.String()
+		record.PeerSerial = fmt.Sprintf("%X", cert.SerialNumber)
+		notAfter := cert.NotAfter.UTC()
+		record.PeerNotAfter = &notAfter
+	}
+	if err := conn.GetVerifyResults(); err != nil {
+		record.Verification = err.Error()
+	}
+	return record
+}
+
+// write appends record to the audit file, creating it if necessary.
+func (a *auditLog) write(record auditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	file, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,77 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestAuditRecords(t *testing.T) {
+	dir, cleanup := testDir(t, "audit")
+	defer cleanup()
+
+	ca := newTestCA(t, "Audit Test CA")
+	caFile := pemFile(t, dir, "ca.pem", ca)
+	server := newServerCert(t, "server", ca)
+
+	cases := []struct {
+		MaxVersion uint16
+		Protocol   string
+	}{
+		{MaxVersion: tls.VersionTLS12, Protocol: "TLSv1.2"},
+		{MaxVersion: tls.VersionTLS13, Protocol: "TLSv1.3"},
+	}
+
+	for i, v := range cases {
+		address, closeServer := configuredTLSServer(t, &tls.Config{MaxVersion: v.MaxVersion}, 0, server)
+		auditFile := filepath.Join(dir, v.Protocol+".log")
+		connector := localConnector(t, address, caFile, func(opts *options.ToolOptions) {
+			opts.SSLAuditFile = auditFile
+		})
+		conn, err := connector.dial(address)
+		if err != nil {
+			t.Fatalf("%v: error connecting: %v", i, err)
+		}
+		conn.Close()
+		connector.Close()
+		closeServer()
+
+		data, err := ioutil.ReadFile(auditFile)
+		if err != nil {
+			t.Fatalf("%v: error reading the audit file: %v", i, err)
+		}
+		var record map[string]interface{}
+		if err = json.Unmarshal([]byte(strings.TrimSpace(string(data))), &record); err != nil {
+			t.Fatalf("%v: error parsing the audit record %s: %v", i, data, err)
+		}
+		if record["protocol"] != v.Protocol {
+			t.Errorf("%v: audit record protocol is %v, expected %v", i, record["protocol"], v.Protocol)
+		}
+		if _, ok := record["peerNotAfter"]; !ok {
+			t.Errorf("%v: audit record has no peerNotAfter: %s", i, data)
+		}
+	}
+
+	// without a peer certificate there's no expiry to record, rather than a
+	// zero time
+	line, err := json.Marshal(auditRecord{Host: "localhost"})
+	if err != nil {
+		t.Fatalf("Error marshaling audit record: %v", err)
+	}
+	if strings.Contains(string(line), "peerNotAfter") {
+		t.Errorf("audit record without a peer certificate has a peerNotAfter: %s", line)
+	}
+}
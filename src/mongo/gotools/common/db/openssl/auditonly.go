@@ -0,0 +1,64 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+// validateAuditOnlyOptions returns an error if --sslVerifyAuditOnly is
+// combined with an option that already disables verification, since nothing
+// would be audited.
+func validateAuditOnlyOptions(opts options.ToolOptions) error {
+	switch {
+	case opts.SSLAllowInvalidCert:
+		return fmt.Errorf("--sslVerifyAuditOnly can't be used with --sslAllowInvalidCertificates")
+	case opts.SSLTrustOnFirstUse:
+		return fmt.Errorf("--sslVerifyAuditOnly can't be used with --sslTrustOnFirstUse")
+	}
+	return nil
+}
+
+// auditVerifyCallback wraps next, which may be nil, so that every failure it
+// doesn't accept is logged in detail and then accepted anyway.
+func auditVerifyCallback(next openssl.VerifyCallback) openssl.VerifyCallback {
+	return func(ok bool, store *openssl.CertificateStoreCtx) bool {
+		if ok {
+			return true
+		}
+		if next != nil && next(ok, store) {
+			return true
+		}
+		log.Logvf(log.Always, "WARNING: audit-only verification: would reject certificate %v "+
+			"at depth %v of the server's chain: %v", currentSubject(store), store.Depth(), store.Err())
+		return true
+	}
+}
+
+// auditFailure returns err unless verification is audit-only, in which case
+// err is logged as a check that would have rejected the connection to
+// address and nil is returned.
+func (self *SSLDBConnector) auditFailure(address string, err error) error {
+	if err == nil || !self.opts.SSLVerifyAuditOnly {
+		return err
+	}
+	log.Logvf(log.Always, "WARNING: audit-only verification: would reject connection to %v: %v", address, err)
+	return nil
+}
+
+// warnAuditOnly logs that the connection to address was not enforced to be
+// verified, so that audit-only connections can't be mistaken for secure ones.
+func warnAuditOnly(address string) {
+	log.Logvf(log.Always, "WARNING: connected to %v with audit-only verification; "+
+		"certificate and hostname failures are logged but NOT enforced, and this connection may be insecure", address)
+}
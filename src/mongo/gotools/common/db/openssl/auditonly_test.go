@@ -0,0 +1,76 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestValidateAuditOnlyOptions(t *testing.T) {
+	cases := []struct {
+		Name  string
+		SSL   options.SSL
+		Valid bool
+	}{
+		{Name: "audit only", SSL: options.SSL{SSLVerifyAuditOnly: true}, Valid: true},
+		{Name: "invalid certificates allowed", SSL: options.SSL{SSLVerifyAuditOnly: true, SSLAllowInvalidCert: true}},
+		{Name: "trust on first use", SSL: options.SSL{SSLVerifyAuditOnly: true, SSLTrustOnFirstUse: true}},
+	}
+
+	for _, v := range cases {
+		ssl := v.SSL
+		if err := validateAuditOnlyOptions(options.ToolOptions{SSL: &ssl}); (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+	}
+}
+
+func TestVerifyAuditOnly(t *testing.T) {
+	dir, cleanup := testDir(t, "auditonly")
+	defer cleanup()
+
+	ca := newTestCA(t, "Audit Only Test CA")
+	good, closeGood := tlsServer(t, newServerCert(t, "good", ca))
+	defer closeGood()
+	wrongHost, closeWrongHost := tlsServer(t, newTestCert(t, "wrong host", x509.Certificate{DNSNames: []string{"elsewhere"}}, ca))
+	defer closeWrongHost()
+	wrongCA, closeWrongCA := tlsServer(t, newServerCert(t, "wrong CA", newTestCA(t, "Untrusted CA")))
+	defer closeWrongCA()
+	caFile := pemFile(t, dir, "ca.pem", ca)
+
+	cases := []struct {
+		Name    string
+		Address string
+		Valid   bool
+	}{
+		{Name: "good", Address: good, Valid: true},
+		{Name: "wrong host", Address: wrongHost},
+		{Name: "wrong CA", Address: wrongCA},
+	}
+
+	for _, auditOnly := range []bool{false, true} {
+		connector := localConnector(t, good, caFile, func(opts *options.ToolOptions) {
+			opts.SSLVerifyAuditOnly = auditOnly
+		})
+		for _, v := range cases {
+			conn, err := connector.dial(v.Address)
+			if err == nil {
+				conn.Close()
+			}
+			// with audit-only verification, every failure is let through
+			if expected := v.Valid || auditOnly; (err == nil) != expected {
+				t.Errorf("%v, audit only %v: connected is %v, expected %v: %v", v.Name, auditOnly, err == nil, expected, err)
+			}
+		}
+		connector.Close()
+	}
+}
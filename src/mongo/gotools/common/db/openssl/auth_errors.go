@@ -0,0 +1,98 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"strings"
+)
+
+// AuthFailureReason classifies why authenticating a session failed.
+type AuthFailureReason int
+
+const (
+	// a failure not covered by the other reasons
+	AuthFailureOther AuthFailureReason = iota
+	// the server rejected the credentials. Servers report an unknown user,
+	// and a user that exists in a database other than the authentication
+	// database, the same way as a wrong password, so those can't be told
+	// apart from it.
+	AuthFailureBadCredentials
+	// the user doesn't exist; only reported by older servers
+	AuthFailureUserNotFound
+	// the mechanism requires a different authentication database, such as
+	// $external
+	AuthFailureWrongDatabase
+	// the mechanism isn't supported by the server or by this build
+	AuthFailureUnsupportedMechanism
+	// the user authenticated but lacks the privileges for a command
+	AuthFailureUnauthorized
+)
+
+func (r AuthFailureReason) String() string {
+	switch r {
+	case AuthFailureBadCredentials:
+		return "bad credentials"
+	case AuthFailureUserNotFound:
+		return "user not found"
+	case AuthFailureWrongDatabase:
+		return "wrong authentication database"
+	case AuthFailureUnsupportedMechanism:
+		return "unsupported mechanism"
+	case AuthFailureUnauthorized:
+		return "unauthorized"
+	}
+	return "other"
+}
+
+// AuthError is returned when a session can't be established because
+// authentication failed.
+type AuthError struct {
+	Reason AuthFailureReason
+	// the mechanism and authentication database that were used; the
+	// mechanism is empty if it was left to be negotiated
+	Mechanism string
+	Source    string
+	Err       error
+}
+
+func (e *AuthError) Error() string {
+	return e.Err.Error()
+}
+
+// authErrorReasons maps fragments of the messages mgo and servers return for
+// failed authentication to their reason. mgo only passes on the server's
+// message, not its error code, so the message is all there is to go by. More
+// specific fragments come first.
+var authErrorReasons = []struct {
+	fragment string
+	reason   AuthFailureReason
+}{
+	{"could not find user", AuthFailureUserNotFound},
+	{"usernotfound", AuthFailureUserNotFound},
+	{"$external", AuthFailureWrongDatabase},
+	{"unknown or not enabled", AuthFailureUnsupportedMechanism},
+	{"unsupported mechanism", AuthFailureUnsupportedMechanism},
+	{"sasl support not enabled", AuthFailureUnsupportedMechanism},
+	{"not authorized", AuthFailureUnauthorized},
+	{"unauthorized", AuthFailureUnauthorized},
+	{"authentication failed", AuthFailureBadCredentials},
+	{"auth fail", AuthFailureBadCredentials},
+}
+
+// newAuthError returns err classified as an authentication failure using
+// mechanism and source, or nil if it isn't one.
+func newAuthError(err error, mechanism, source string) *AuthError {
+	message := strings.ToLower(err.Error())
+	for _, candidate := range authErrorReasons {
+		if strings.Contains(message, candidate.fragment) {
+			return &AuthError{Reason: candidate.reason, Mechanism: mechanism, Source: source, Err: err}
+		}
+	}
+	return nil
+}
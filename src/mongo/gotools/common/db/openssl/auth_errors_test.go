@@ -0,0 +1,94 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestNewAuthError(t *testing.T) {
+	cases := []struct {
+		Message string
+		Auth    bool
+		Reason  AuthFailureReason
+	}{
+		{Message: "server returned error on SASL authentication step: Authentication failed.", Auth: true, Reason: AuthFailureBadCredentials},
+		{Message: "auth fails", Auth: true, Reason: AuthFailureBadCredentials},
+		{Message: "Could not find user alice@admin", Auth: true, Reason: AuthFailureUserNotFound},
+		{Message: "UserNotFound: no such user", Auth: true, Reason: AuthFailureUserNotFound},
+		{Message: "mechanism MONGODB-X509 requires the $external database", Auth: true, Reason: AuthFailureWrongDatabase},
+		{Message: "Received authentication for mechanism PLAIN which is unknown or not enabled", Auth: true, Reason: AuthFailureUnsupportedMechanism},
+		{Message: "SASL support not enabled during build (-tags sasl)", Auth: true, Reason: AuthFailureUnsupportedMechanism},
+		{Message: "not authorized on admin to execute command { listDatabases: 1 }", Auth: true, Reason: AuthFailureUnauthorized},
+		{Message: "no reachable servers"},
+		{Message: "connection refused"},
+	}
+
+	for _, v := range cases {
+		err := errors.New(v.Message)
+		authErr := newAuthError(err, "SCRAM-SHA-1", "admin")
+		if (authErr != nil) != v.Auth {
+			t.Errorf("%q: auth failure is %v, expected %v", v.Message, authErr != nil, v.Auth)
+			continue
+		}
+		if authErr == nil {
+			continue
+		}
+		if authErr.Reason != v.Reason {
+			t.Errorf("%q: reason is %v, expected %v", v.Message, authErr.Reason, v.Reason)
+		}
+		if authErr.Err != err || authErr.Error() != v.Message || authErr.Mechanism != "SCRAM-SHA-1" || authErr.Source != "admin" {
+			t.Errorf("%q: error doesn't keep its cause and context: %+v", v.Message, authErr)
+		}
+	}
+}
+
+func TestGetNewSessionAuthError(t *testing.T) {
+	dir, cleanup := testDir(t, "auth")
+	defer cleanup()
+
+	ca := newTestCA(t, "Auth Test CA")
+	server := newServerCert(t, "server", ca)
+	// a server that rejects every authentication attempt
+	address, closeServer := tlsWireServer(t, server, func(database string, command bson.D) bson.M {
+		switch command[0].Name {
+		case "getnonce":
+			return bson.M{"nonce": "2375531c32080ae8", "ok": 1}
+		case "authenticate", "saslStart":
+			return bson.M{"ok": 0, "errmsg": "auth fails", "code": 18}
+		}
+		return mongodReply(database, command)
+	})
+	defer closeServer()
+
+	connector := localConnector(t, address, pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.Timeout = 5
+		opts.Auth.Username = "alice"
+		opts.Auth.Password = "wrong"
+		opts.Auth.Source = "admin"
+	})
+	defer connector.Close()
+
+	session, err := connector.GetNewSession()
+	if err == nil {
+		session.Close()
+		t.Fatalf("Expected authentication to fail")
+	}
+	authErr, ok := err.(*AuthError)
+	if !ok {
+		t.Fatalf("Expected an *AuthError, got %T: %v", err, err)
+	}
+	if authErr.Reason != AuthFailureBadCredentials || authErr.Source != "admin" {
+		t.Errorf("Error is %v against %v, expected %v against admin", authErr.Reason, authErr.Source, AuthFailureBadCredentials)
+	}
+}
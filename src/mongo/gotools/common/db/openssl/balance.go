@@ -0,0 +1,70 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// mongosBalancer spreads new sessions across the seed mongos addresses in
+// turn. Each session is connected directly to a single mongos, since mgo
+// otherwise sends every operation of a session through the same one.
+type mongosBalancer struct {
+	mu   sync.Mutex
+	next int
+}
+
+// dial connects a session to the next mongos in turn, moving on to the ones
+// after it if it can't be reached.
+func (b *mongosBalancer) dial(dialInfo *mgo.DialInfo) (*mgo.Session, error) {
+	addrs := dialInfo.Addrs
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no mongos addresses to connect to")
+	}
+	b.mu.Lock()
+	start := b.next % len(addrs)
+	b.next = start + 1
+	b.mu.Unlock()
+
+	errs := make([]string, 0, len(addrs))
+	for i := range addrs {
+		addr := addrs[(start+i)%len(addrs)]
+		info := *dialInfo
+		info.Addrs = []string{addr}
+		info.Direct = true
+		session, err := mgo.DialWithInfo(&info)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", addr, err))
+			continue
+		}
+		if err = checkMongos(session); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("%v: %v", addr, err)
+		}
+		return session, nil
+	}
+	return nil, fmt.Errorf("no reachable mongos (%v)", strings.Join(errs, "; "))
+}
+
+// checkMongos returns an error unless session is connected to a mongos.
+func checkMongos(session *mgo.Session) error {
+	result := bson.M{}
+	if err := session.Run("isMaster", &result); err != nil {
+		return fmt.Errorf("error running isMaster: %v", err)
+	}
+	if result["msg"] != "isdbgrid" {
+		return fmt.Errorf("load balancing requires every seed to be a mongos")
+	}
+	return nil
+}
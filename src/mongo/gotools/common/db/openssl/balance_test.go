@@ -0,0 +1,63 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+func TestMongosBalancerRotates(t *testing.T) {
+	var mu sync.Mutex
+	var dialed []string
+	dialInfo := &mgo.DialInfo{
+		Addrs:    []string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3"},
+		Timeout:  100 * time.Millisecond,
+		FailFast: true,
+		DialServer: func(addr *mgo.ServerAddr) (net.Conn, error) {
+			mu.Lock()
+			dialed = append(dialed, addr.String())
+			mu.Unlock()
+			return nil, fmt.Errorf("unreachable")
+		},
+	}
+
+	balancer := &mongosBalancer{}
+	for i, expected := range []string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3", "127.0.0.1:1"} {
+		mu.Lock()
+		dialed = nil
+		mu.Unlock()
+		_, err := balancer.dial(dialInfo)
+		if err == nil {
+			t.Fatalf("Dial %v: expected an error", i)
+		}
+		for _, addr := range dialInfo.Addrs {
+			if !strings.Contains(err.Error(), addr) {
+				t.Errorf("Dial %v: error should mention %v: %v", i, addr, err)
+			}
+		}
+		mu.Lock()
+		if len(dialed) == 0 || dialed[0] != expected {
+			t.Errorf("Dial %v: dialed %v first, expected %v", i, dialed, expected)
+		}
+		mu.Unlock()
+	}
+}
+
+func TestMongosBalancerNoAddresses(t *testing.T) {
+	if _, err := (&mongosBalancer{}).dial(&mgo.DialInfo{}); err == nil {
+		t.Errorf("Expected an error with no mongos addresses")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var errDialBudgetExhausted = errors.New("connection attempt limit reached")
+
+// dialBudget limits the connection attempts made while establishing a
+// session. Once the session is established, or establishing it has failed,
+// any further attempts mgo makes are not limited. A nil budget allows every
+// attempt.
+type dialBudget struct {
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	done      bool
+	attempts  []string
+}
+
+func newDialBudget(limit int) *dialBudget {
+	return &dialBudget{limit: limit, remaining: limit}
+}
+
+// take reports whether another attempt may be made, counting it if so.
+func (b *dialBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return true
+	}
+	if b.remaining == 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// record notes the outcome of an attempt to connect to host.
+func (b *dialBudget) record(host string, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return
+	}
+	if err != nil {
+		b.attempts = append(b.attempts, fmt.Sprintf("%v: %v", host, err))
+	} else {
+		b.attempts = append(b.attempts, fmt.Sprintf("%v: connected", host))
+	}
+}
+
+// finish stops limiting attempts. If establishing the session failed with err
+// after the budget ran out, it returns an error listing every attempt.
+func (b *dialBudget) finish(err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = true
+	if err == nil || b.remaining > 0 {
+		return err
+	}
+	return fmt.Errorf("%v after %v connection attempts (%v)", err, b.limit, strings.Join(b.attempts, "; "))
+}
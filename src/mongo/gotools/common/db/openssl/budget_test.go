@@ -0,0 +1,105 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestDialBudget(t *testing.T) {
+	var unlimited *dialBudget
+	if !unlimited.take() {
+		t.Errorf("A nil budget should allow every attempt")
+	}
+
+	budget := newDialBudget(2)
+	if !budget.take() || !budget.take() {
+		t.Fatalf("Expected the first two attempts to be allowed")
+	}
+	budget.record("host1:27017", errors.New("connection refused"))
+	budget.record("host2:27017", nil)
+	if budget.take() {
+		t.Errorf("Expected the third attempt to be refused")
+	}
+	err := budget.finish(errors.New("no reachable servers"))
+	if err == nil {
+		t.Fatalf("Expected an error once the budget is exhausted")
+	}
+	for _, part := range []string{"no reachable servers", "after 2 connection attempts", "host1:27017: connection refused", "host2:27017: connected"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("Error should mention %q: %v", part, err)
+		}
+	}
+	if !budget.take() {
+		t.Errorf("Attempts after the session is established shouldn't be limited")
+	}
+
+	budget = newDialBudget(2)
+	budget.take()
+	original := errors.New("no reachable servers")
+	if err := budget.finish(original); err != original {
+		t.Errorf("Error shouldn't change if the budget wasn't exhausted: %v", err)
+	}
+	if err := newDialBudget(2).finish(nil); err != nil {
+		t.Errorf("Unexpected error for an established session: %v", err)
+	}
+}
+
+func TestTotalDialAttempts(t *testing.T) {
+	hosts := []string{closedAddr(t), closedAddr(t), closedAddr(t)}
+	connector := localConnector(t, strings.Join(hosts, ","), "testdata/ca.pem", func(opts *options.ToolOptions) {
+		opts.Timeout = 1
+		opts.TotalDialAttempts = 2
+	})
+	defer connector.Close()
+
+	session, err := connector.GetNewSession()
+	if err == nil {
+		session.Close()
+		t.Fatalf("Expected an error connecting to closed ports")
+	}
+	if !strings.Contains(err.Error(), "after 2 connection attempts") {
+		t.Errorf("Error should report the exhausted budget: %v", err)
+	}
+	if attempts := connector.Metrics().Attempts; attempts != 2 {
+		t.Errorf("Made %v connection attempts, expected 2", attempts)
+	}
+}
+
+func TestTotalDialAttemptsValidation(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Attempts int
+		Share    bool
+		Valid    bool
+	}{
+		{Name: "no limit", Valid: true},
+		{Name: "limit", Attempts: 5, Valid: true},
+		{Name: "negative limit", Attempts: -1},
+		{Name: "limit with shared sessions", Attempts: 5, Share: true},
+	}
+
+	for _, v := range cases {
+		opts := testOptions("localhost")
+		opts.TotalDialAttempts = v.Attempts
+		opts.ShareSessions = v.Share
+		connector := &SSLDBConnector{}
+		err := connector.Configure(opts)
+		if (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+		if err == nil {
+			connector.Close()
+		}
+	}
+}
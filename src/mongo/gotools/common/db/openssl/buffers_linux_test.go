@@ -0,0 +1,38 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"net"
+	"syscall"
+	"testing"
+)
+
+// checkSocketBuffers checks that both buffers of conn were set to size bytes,
+// which Linux reports doubled to allow for its own overhead.
+func checkSocketBuffers(t *testing.T, conn net.Conn, size int) {
+	tcp, ok := conn.(*net.TCPConn)
+	if !ok {
+		t.Fatalf("Expected a TCP connection, got %T", conn)
+	}
+	raw, err := tcp.SyscallConn()
+	if err != nil {
+		t.Fatalf("Error getting the raw connection: %v", err)
+	}
+	raw.Control(func(fd uintptr) {
+		for name, option := range map[string]int{"read": syscall.SO_RCVBUF, "write": syscall.SO_SNDBUF} {
+			buffer, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, option)
+			if err != nil {
+				t.Errorf("Error getting the %v buffer size: %v", name, err)
+			} else if buffer != 2*size {
+				t.Errorf("The %v buffer is %v bytes, expected %v", name, buffer, 2*size)
+			}
+		}
+	})
+}
@@ -0,0 +1,18 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0,!linux
+
+package openssl
+
+import (
+	"net"
+	"testing"
+)
+
+// checkSocketBuffers does nothing where the buffer sizes the OS reports
+// aren't predictable.
+func checkSocketBuffers(t *testing.T, conn net.Conn, size int) {}
@@ -0,0 +1,67 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestSocketBufferOptions(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Read, Write int
+		Valid       bool
+	}{
+		{Name: "OS defaults", Valid: true},
+		{Name: "both set", Read: 1 << 20, Write: 1 << 20, Valid: true},
+		{Name: "negative read buffer", Read: -1},
+		{Name: "negative write buffer", Write: -1},
+	}
+
+	for _, v := range cases {
+		opts := testOptions("localhost")
+		opts.SocketReadBuffer = v.Read
+		opts.SocketWriteBuffer = v.Write
+		connector := &SSLDBConnector{}
+		err := connector.Configure(opts)
+		if (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+		if err == nil {
+			connector.Close()
+		}
+	}
+}
+
+func TestSocketBuffers(t *testing.T) {
+	dir, cleanup := testDir(t, "buffers")
+	defer cleanup()
+
+	ca := newTestCA(t, "Buffers Test CA")
+	address, closeServer := tlsServer(t, newServerCert(t, "server", ca))
+	defer closeServer()
+
+	// smaller than the OS defaults, so a size that wasn't applied shows, and
+	// too small to be capped by the maximum the OS allows
+	const size = 8192
+	connector := localConnector(t, address, pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.SocketReadBuffer = size
+		opts.SocketWriteBuffer = size
+	})
+	defer connector.Close()
+
+	conn, err := connector.dial(address)
+	if err != nil {
+		t.Fatalf("Error dialing with socket buffer sizes set: %v", err)
+	}
+	defer conn.Close()
+	checkSocketBuffers(t, tcpConn(conn.UnderlyingConn()), size)
+}
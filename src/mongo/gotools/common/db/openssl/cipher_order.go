@@ -0,0 +1,93 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mongodb/mongo-tools/common/log"
+)
+
+// maxCipherProbes bounds the handshakes made to learn a server's cipher
+// order, in case the server never runs out of ciphers.
+const maxCipherProbes = 64
+
+// matchServerCiphers learns the order in which the server at address prefers
+// the ciphers of base, by repeatedly connecting and excluding the cipher the
+// server chose, and sets the ctx to offer them in that order. Only the order
+// of ciphers before TLS 1.3 can be set, so it fails if the server negotiates
+// TLS 1.3. The order is complete once the server rejects the handshake for
+// want of a shared cipher; if probing fails any other way, the ctx is left
+// offering base and the error is returned.
+func (self *SSLDBConnector) matchServerCiphers(address, base string) error {
+	var order []string
+	list := base
+	for len(order) < maxCipherProbes {
+		// setting the list fails once it no longer selects any cipher
+		if err := self.ctx.SetCipherList(list); err != nil {
+			break
+		}
+		cipher, phase, err := self.probeCipher(address)
+		if err != nil {
+			if len(order) > 0 && phase == failureHandshake && isNoSharedCipher(err) {
+				break
+			}
+			if resetErr := self.ctx.SetCipherList(base); resetErr != nil {
+				return fmt.Errorf("SetCipherList(%v): %v", base, resetErr)
+			}
+			return fmt.Errorf("error probing %v for its cipher order: %v", address, err)
+		}
+		order = append(order, cipher)
+		list += ":!" + cipher
+	}
+
+	matched := strings.Join(order, ":")
+	if err := self.ctx.SetCipherList(matched); err != nil {
+		return fmt.Errorf("SetCipherList(%v): %v", matched, err)
+	}
+	log.Logvf(log.DebugLow, "using the cipher order of %v: %v", address, matched)
+	return nil
+}
+
+// probeCipher connects to the server at address and returns the cipher it
+// chose, or the phase the connection failed in along with the error.
+func (self *SSLDBConnector) probeCipher(address string) (string, string, error) {
+	conn, phases, err := self.connect(address, 0)
+	if err != nil {
+		return "", phases.failed, err
+	}
+	defer conn.Close()
+	cipher, err := conn.CurrentCipher()
+	if err != nil {
+		return "", failureHandshake, err
+	}
+	if recorder, ok := conn.UnderlyingConn().(*helloRecorder); ok {
+		if negotiated, err := recorder.extensions(); err == nil && negotiated.TLS13 {
+			return "", failureHandshake,
+				fmt.Errorf("server negotiated TLS 1.3 ciphersuite %v, whose order can't be set by this build", cipher)
+		}
+	}
+	return cipher, "", nil
+}
+
+// isNoSharedCipher reports whether err is the server rejecting a probe's
+// handshake for want of a shared cipher. Servers usually say so with a plain
+// handshake failure alert, which can mean other things too; but an earlier
+// probe with the same settings succeeded, and only the cipher list has
+// changed since.
+func isNoSharedCipher(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, fragment := range append(cipherMismatchErrors, "alert handshake failure") {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+)
+
+func TestMatchServerCiphers(t *testing.T) {
+	dir, cleanup := testDir(t, "ciphers")
+	defer cleanup()
+
+	ca := newTestCA(t, "Cipher Test CA")
+	caFile := pemFile(t, dir, "ca.pem", ca)
+	server := newServerCert(t, "server", ca)
+	config := &tls.Config{
+		MaxVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		},
+	}
+	// whether a connection to a server accepting only cipher succeeds
+	connects := func(connector *SSLDBConnector, cipher uint16) bool {
+		only := &tls.Config{MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{cipher}}
+		address, closeServer := configuredTLSServer(t, only, 0, server)
+		defer closeServer()
+		conn, _, err := connector.connect(address, 0)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+
+	cases := []struct {
+		Name    string
+		Accepts int
+		Error   string
+	}{
+		{Name: "every shared cipher", Accepts: 0},
+		// the first probe succeeds, but the server is gone by the second
+		{Name: "server stops listening", Accepts: 1, Error: "error probing"},
+	}
+
+	for _, v := range cases {
+		address, closeServer := configuredTLSServer(t, config, v.Accepts, server)
+		connector := localConnector(t, address, caFile, nil)
+		err := connector.matchServerCiphers(address, offeredCiphers(connector.opts))
+		closeServer()
+
+		if v.Error == "" && err != nil {
+			t.Errorf("%v: unexpected error: %v", v.Name, err)
+		} else if v.Error != "" && (err == nil || !strings.Contains(err.Error(), v.Error)) {
+			t.Errorf("%v: expected an error mentioning %q, got %v", v.Name, v.Error, err)
+		}
+		// both ciphers the server accepts are still offered
+		if !connects(connector, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384) {
+			t.Errorf("%v: the second cipher the server accepts is no longer offered", v.Name)
+		}
+		// once the order is matched, nothing the server didn't accept is;
+		// if matching failed, the original list is
+		other := connects(connector, tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA)
+		if v.Error == "" && other {
+			t.Errorf("%v: a cipher the server doesn't accept is still offered", v.Name)
+		} else if v.Error != "" && !other {
+			t.Errorf("%v: the cipher list wasn't reset after matching failed", v.Name)
+		}
+		connector.Close()
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"net"
+
+	"gopkg.in/mgo.v2"
+)
+
+// Clone returns a new connector configured like this one, without setting up
+// another ssl context. The clone shares the ctx, which is freed once no
+// connector refers to it, along with the certificate-related state built from
+// it, such as the audit log and known hosts. It gets its own copy of the dial
+// info, which OverrideDialInfo can change, and its own sessions, metrics and
+// connection history. Closing either connector doesn't affect the other.
+func (self *SSLDBConnector) Clone() (*SSLDBConnector, error) {
+	if self.dialInfo == nil {
+		return nil, fmt.Errorf("can't clone a connector that hasn't been configured")
+	}
+	clone := &SSLDBConnector{connectorConfig: self.connectorConfig}
+	if self.balancer != nil {
+		clone.balancer = &mongosBalancer{}
+	}
+	if self.attempts != nil {
+		clone.attempts = &attemptLog{
+			secrets: self.attempts.secrets,
+			records: make([]AttemptRecord, len(self.attempts.records)),
+		}
+	}
+	if self.tracker != nil {
+		clone.tracker = newConnTracker()
+	}
+
+	dialInfo := *self.dialInfo
+	dialInfo.Addrs = append([]string(nil), self.dialInfo.Addrs...)
+	clone.dialInfo = &dialInfo
+	clone.bindDialer()
+
+	if self.srv != nil {
+		clone.srv = newSRVPoller(self.srv.host, self.srv.current(), self.srv.interval)
+	}
+	if self.standby != nil {
+		clone.standby = newWarmStandby(func() (*mgo.Session, error) {
+			return clone.newSession(nil)
+		}, clone.standbyInterval())
+	}
+	return clone, nil
+}
+
+// OverrideDialInfo calls override with the connector's dial info so that it
+// can be changed, for instance to connect a clone to other hosts or as
+// another user. It must be called before the connector creates its first
+// session. The dialer can't be overridden, since it's what makes connections
+// use ssl.
+func (self *SSLDBConnector) OverrideDialInfo(override func(dialInfo *mgo.DialInfo)) error {
+	if self.dialInfo == nil {
+		return fmt.Errorf("can't override the dial info of a connector that hasn't been configured")
+	}
+	override(self.dialInfo)
+	self.bindDialer()
+	if self.srv != nil {
+		// keep polling, but from the overridden seed list
+		host, interval := self.srv.host, self.srv.interval
+		self.srv.close()
+		self.srv = newSRVPoller(host, self.dialInfo.Addrs, interval)
+	}
+	if self.poolKey != "" {
+		self.poolKey = self.sessionPoolKey()
+	}
+	return nil
+}
+
+// bindDialer sets the dial info to connect through this connector, so that
+// its connections are counted and tracked by it rather than by the connector
+// it was cloned from.
+func (self *SSLDBConnector) bindDialer() {
+	self.dialInfo.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
+		conn, err := self.dial(addr.String())
+		if err != nil {
+			return nil, err
+		}
+		return self.serverConn(conn), nil
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"net"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2"
+)
+
+func TestClone(t *testing.T) {
+	if _, err := (&SSLDBConnector{}).Clone(); err == nil {
+		t.Errorf("Expected an error cloning a connector that hasn't been configured")
+	}
+
+	connector := configuredConnector(t, "host1,host2", func(opts *options.ToolOptions) {
+		opts.WriteConcern = "majority"
+		opts.MongosLoadBalance = true
+		opts.ConnectionAttemptLogSize = 4
+	})
+	defer connector.Close()
+	connector.metrics.attempt()
+	connector.lastConn.set(&net.TCPAddr{}, &net.TCPAddr{})
+
+	clone, err := connector.Clone()
+	if err != nil {
+		t.Fatalf("Error cloning connector: %v", err)
+	}
+	defer clone.Close()
+
+	if clone.ctx != connector.ctx {
+		t.Errorf("The clone should share the ctx")
+	}
+	if clone.safe == nil || clone.safe.WMode != "majority" {
+		t.Errorf("The clone should have the write concern, got %+v", clone.safe)
+	}
+	if clone.balancer == nil || clone.balancer == connector.balancer {
+		t.Errorf("The clone should have its own balancer")
+	}
+	if clone.attempts == nil || clone.attempts == connector.attempts || len(clone.attempts.records) != 4 {
+		t.Errorf("The clone should have its own attempt log of the same size")
+	}
+	if metrics := clone.Metrics(); metrics.Attempts != 0 {
+		t.Errorf("The clone shouldn't inherit metrics, got %v attempts", metrics.Attempts)
+	}
+	if _, _, err := clone.ConnAddrs(); err == nil {
+		t.Errorf("The clone shouldn't inherit the last connection")
+	}
+
+	err = clone.OverrideDialInfo(func(dialInfo *mgo.DialInfo) {
+		dialInfo.Addrs[0] = "other:27017"
+		dialInfo.Username = "other"
+	})
+	if err != nil {
+		t.Fatalf("Error overriding dial info: %v", err)
+	}
+	if connector.dialInfo.Addrs[0] != "host1:27017" || connector.dialInfo.Username != "" {
+		t.Errorf("Overriding the clone's dial info changed the original's: %+v", connector.dialInfo)
+	}
+	if clone.dialInfo.DialServer == nil {
+		t.Errorf("The clone should still connect over ssl")
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+)
+
+// isGlobPattern reports whether path contains any of the characters that
+// filepath.Match treats specially.
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// loadCRLFiles loads every CRL file matching pattern into lookup.
+func loadCRLFiles(lookup *openssl.CertificateStoreLookup, pattern string) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid CRL file pattern '%v': %v", pattern, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no CRL files match '%v'", pattern)
+	}
+	for _, file := range files {
+		if err = lookup.LoadCRLFile(file); err != nil {
+			return fmt.Errorf("error loading CRL file %v: %v", file, err)
+		}
+	}
+	log.Logvf(log.DebugLow, "loaded %v CRL files matching '%v'", len(files), pattern)
+	return nil
+}
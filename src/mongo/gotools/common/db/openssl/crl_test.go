@@ -0,0 +1,102 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestIsGlobPattern(t *testing.T) {
+	cases := []struct {
+		Path string
+		Glob bool
+	}{
+		{Path: "/etc/crls/ca.crl"},
+		{Path: "/etc/crls/*.crl", Glob: true},
+		{Path: "/etc/crls/ca?.crl", Glob: true},
+		{Path: "/etc/crls/ca[12].crl", Glob: true},
+	}
+
+	for _, v := range cases {
+		if glob := isGlobPattern(v.Path); glob != v.Glob {
+			t.Errorf("%v: glob is %v, expected %v", v.Path, glob, v.Glob)
+		}
+	}
+}
+
+func TestLoadCRLFiles(t *testing.T) {
+	dir, cleanup := testDir(t, "crl")
+	defer cleanup()
+
+	ca := newTestCA(t, "CRL Test CA")
+	crlFile(t, dir, "one.crl", ca)
+	crlFile(t, dir, "two.crl", newTestCA(t, "Other CRL Test CA"))
+	pemFile(t, dir, "not-a-crl.pem", ca)
+
+	cases := []struct {
+		Name    string
+		Pattern string
+		Valid   bool
+	}{
+		{Name: "every CRL file", Pattern: filepath.Join(dir, "*.crl"), Valid: true},
+		{Name: "no matching files", Pattern: filepath.Join(dir, "*.missing")},
+		{Name: "malformed pattern", Pattern: filepath.Join(dir, "[")},
+		{Name: "a file that isn't a CRL", Pattern: filepath.Join(dir, "*.pem")},
+	}
+
+	for _, v := range cases {
+		ctx, err := openssl.NewCtx()
+		if err != nil {
+			t.Fatalf("Error creating ctx: %v", err)
+		}
+		lookup, err := ctx.GetCertificateStore().AddLookup(openssl.X509LookupFile())
+		if err != nil {
+			t.Fatalf("Error adding lookup: %v", err)
+		}
+		if err := loadCRLFiles(lookup, v.Pattern); (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+	}
+}
+
+func TestCRLFilePattern(t *testing.T) {
+	dir, cleanup := testDir(t, "crl")
+	defer cleanup()
+
+	first := newCRLIssuer(t, "First CRL Test CA")
+	second := newCRLIssuer(t, "Second CRL Test CA")
+	revokedCert := newServerCert(t, "revoked", first)
+	revoked, closeRevoked := tlsServer(t, revokedCert)
+	defer closeRevoked()
+	good, closeGood := tlsServer(t, newServerCert(t, "good", second))
+	defer closeGood()
+	crlFile(t, dir, "first.crl", first, revokedCert)
+	crlFile(t, dir, "second.crl", second)
+
+	// every server's certificate is checked against a CRL from its issuer, so
+	// the good server is only accepted if both files were loaded
+	connector := localConnector(t, good, pemFile(t, dir, "ca.pem", first, second), func(opts *options.ToolOptions) {
+		opts.SSLCRLFile = filepath.Join(dir, "*.crl")
+	})
+	defer connector.Close()
+
+	if conn, err := connector.dial(good); err != nil {
+		t.Errorf("Error dialing a server whose certificate isn't revoked: %v", err)
+	} else {
+		conn.Close()
+	}
+	if conn, err := connector.dial(revoked); err == nil {
+		conn.Close()
+		t.Errorf("Expected an error dialing a server whose certificate is revoked")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces secrets found in recorded errors.
+const redacted = "<redacted>"
+
+// AttemptRecord describes a single connection attempt. Phase is the phase
+// that failed, as passed to the error formatter, or empty if the attempt
+// succeeded. Establishing a session that fails after its connections were
+// made, such as by failing to authenticate, is recorded as a failed attempt
+// in the "session" phase, with Host holding the comma separated seed list.
+type AttemptRecord struct {
+	Timestamp time.Time
+	Host      string
+	Phase     string
+	Outcome   string
+	Error     string
+}
+
+// attemptLog remembers the most recent connection attempts in a fixed size
+// ring buffer.
+type attemptLog struct {
+	secrets []string
+
+	mu      sync.Mutex
+	records []AttemptRecord
+	next    int
+	full    bool
+}
+
+// newAttemptLog returns a log of the last size attempts, which removes any of
+// secrets from the errors it records.
+func newAttemptLog(size int, secrets ...string) *attemptLog {
+	l := &attemptLog{records: make([]AttemptRecord, size)}
+	for _, secret := range secrets {
+		if secret != "" {
+			l.secrets = append(l.secrets, secret)
+		}
+	}
+	return l
+}
+
+// add records an attempt to connect to address, replacing the oldest one if
+// the log is full. It does nothing on a nil log.
+func (l *attemptLog) add(address, phase string, err error) {
+	if l == nil {
+		return
+	}
+	record := AttemptRecord{
+		Timestamp: time.Now().UTC(),
+		Host:      address,
+		Outcome:   OutcomeSuccess,
+	}
+	if err != nil {
+		record.Phase = phase
+		record.Outcome = OutcomeFailure
+		record.Error = l.redact(err.Error())
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records[l.next] = record
+	if l.next++; l.next == len(l.records) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// redact replaces every secret in message.
+func (l *attemptLog) redact(message string) string {
+	for _, secret := range l.secrets {
+		message = strings.Replace(message, secret, redacted, -1)
+	}
+	return message
+}
+
+// recent returns the recorded attempts, oldest first.
+func (l *attemptLog) recent() []AttemptRecord {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.full {
+		return append([]AttemptRecord(nil), l.records[:l.next]...)
+	}
+	return append(append([]AttemptRecord(nil), l.records[l.next:]...), l.records[:l.next]...)
+}
+
+// RecentAttempts returns the most recent connection attempts, oldest first,
+// up to the size of the connection attempt log. It returns nil if the log
+// isn't enabled.
+func (self *SSLDBConnector) RecentAttempts() []AttemptRecord {
+	return self.attempts.recent()
+}
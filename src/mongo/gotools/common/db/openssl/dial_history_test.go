@@ -0,0 +1,145 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAttemptLog(t *testing.T) {
+	var disabled *attemptLog
+	disabled.add("host:27017", failureTCP, errors.New("refused"))
+	if recent := disabled.recent(); recent != nil {
+		t.Errorf("A disabled log should record nothing: %v", recent)
+	}
+
+	attempts := newAttemptLog(3)
+	attempts.add("host0:27017", "", nil)
+	attempts.add("host1:27017", failureHandshake, errors.New("handshake failed"))
+	if recent := attempts.recent(); len(recent) != 2 || recent[0].Host != "host0:27017" || recent[1].Host != "host1:27017" {
+		t.Fatalf("Expected both attempts, oldest first: %+v", recent)
+	}
+	for i := 2; i < 5; i++ {
+		attempts.add(fmt.Sprintf("host%v:27017", i), failureTCP, errors.New("refused"))
+	}
+
+	recent := attempts.recent()
+	if len(recent) != 3 {
+		t.Fatalf("Expected the log to hold 3 attempts, got %v", len(recent))
+	}
+	for i, record := range recent {
+		if host := fmt.Sprintf("host%v:27017", i+2); record.Host != host {
+			t.Errorf("Attempt %v is to %v, expected %v", i, record.Host, host)
+		}
+		if record.Outcome != OutcomeFailure || record.Phase != failureTCP || record.Timestamp.IsZero() {
+			t.Errorf("Attempt %v is recorded wrongly: %+v", i, record)
+		}
+	}
+
+	// the returned records are a copy
+	recent[0].Host = "changed"
+	if attempts.recent()[0].Host == "changed" {
+		t.Errorf("Changing the returned records shouldn't change the log")
+	}
+}
+
+func TestAttemptLogRedaction(t *testing.T) {
+	attempts := newAttemptLog(2, "hunter2", "pemsecret")
+	attempts.add("host:27017", "", nil)
+	attempts.add("host:27017", failureHandshake, errors.New("hunter2 and pemsecret"))
+
+	recent := attempts.recent()
+	if recent[0].Outcome != OutcomeSuccess || recent[0].Phase != "" || recent[0].Error != "" {
+		t.Errorf("Success is recorded wrongly: %+v", recent[0])
+	}
+	if recent[1].Error != redacted+" and "+redacted {
+		t.Errorf("Secrets should be redacted: %q", recent[1].Error)
+	}
+}
+
+func TestRecentAttempts(t *testing.T) {
+	address := closedAddr(t)
+	connector := localConnector(t, address, "testdata/ca.pem", func(opts *options.ToolOptions) {
+		opts.ConnectionAttemptLogSize = 2
+		opts.Auth.Password = "hunter2"
+	})
+	defer connector.Close()
+
+	if recent := connector.RecentAttempts(); len(recent) != 0 {
+		t.Errorf("Expected no attempts before dialing: %+v", recent)
+	}
+	connector.dial(address)
+	recent := connector.RecentAttempts()
+	if len(recent) != 1 || recent[0].Host != address || recent[0].Phase != failureTCP {
+		t.Errorf("Expected the failed attempt to be recorded: %+v", recent)
+	}
+	for _, record := range recent {
+		if strings.Contains(record.Error, "hunter2") {
+			t.Errorf("The password should never be recorded: %+v", record)
+		}
+	}
+
+	disabled := localConnector(t, address, "testdata/ca.pem", nil)
+	defer disabled.Close()
+	disabled.dial(address)
+	if recent := disabled.RecentAttempts(); recent != nil {
+		t.Errorf("Expected no log unless its size is set: %+v", recent)
+	}
+}
+
+func TestRecentAttemptsSessionFailure(t *testing.T) {
+	dir, cleanup := testDir(t, "history")
+	defer cleanup()
+
+	ca := newTestCA(t, "History Test CA")
+	address, closeServer := tlsWireServer(t, newServerCert(t, "server", ca), func(database string, command bson.D) bson.M {
+		switch command[0].Name {
+		case "getnonce":
+			return bson.M{"nonce": "2375531c32080ae8", "ok": 1}
+		case "authenticate", "saslStart":
+			return bson.M{"ok": 0, "errmsg": "auth fails", "code": 18}
+		}
+		return mongodReply(database, command)
+	})
+	defer closeServer()
+
+	connector := localConnector(t, address, pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.Timeout = 5
+		opts.ConnectionAttemptLogSize = 10
+		opts.Auth.Username = "alice"
+		opts.Auth.Password = "hunter2"
+		opts.Auth.Source = "admin"
+	})
+	defer connector.Close()
+
+	if session, err := connector.GetNewSession(); err == nil {
+		session.Close()
+		t.Fatalf("Expected authentication to fail")
+	}
+	// the connections succeeded, so only the session records the failure
+	recent := connector.RecentAttempts()
+	if len(recent) == 0 {
+		t.Fatalf("Expected the attempts to be recorded")
+	}
+	last := recent[len(recent)-1]
+	if last.Phase != phaseSession || last.Outcome != OutcomeFailure || last.Host != address || last.Error == "" {
+		t.Errorf("Expected the authentication failure to be recorded: %+v", last)
+	}
+	for _, record := range recent[:len(recent)-1] {
+		if record.Outcome != OutcomeSuccess {
+			t.Errorf("Expected the connection to succeed: %+v", record)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+)
+
+// checkExtendedMasterSecret returns an error unless the server negotiated the
+// extended master secret extension. OpenSSL 1.1.0 and later offer it unless
+// configured not to, and TLS 1.3 always binds its keys to the handshake, so
+// TLS 1.3 connections pass.
+func checkExtendedMasterSecret(negotiated NegotiatedExtensions, err error) error {
+	if err != nil {
+		return fmt.Errorf("can't determine whether the extended master secret was negotiated: %v", err)
+	}
+	if negotiated.TLS13 || negotiated.Has(extensionExtendedMasterSecret) {
+		return nil
+	}
+	return fmt.Errorf("server did not negotiate the extended master secret extension, " +
+		"which --sslRequireEMS requires")
+}
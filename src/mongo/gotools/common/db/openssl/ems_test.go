@@ -0,0 +1,63 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestCheckExtendedMasterSecret(t *testing.T) {
+	ems := TLSExtension{ID: extensionExtendedMasterSecret, Name: "extended_master_secret"}
+	cases := []struct {
+		Name       string
+		Negotiated NegotiatedExtensions
+		Err        error
+		Valid      bool
+	}{
+		{Name: "TLS 1.2 with EMS", Negotiated: NegotiatedExtensions{Version: "TLSv1.2", Extensions: []TLSExtension{ems}}, Valid: true},
+		{Name: "TLS 1.2 without EMS", Negotiated: NegotiatedExtensions{Version: "TLSv1.2"}},
+		{Name: "TLS 1.3", Negotiated: NegotiatedExtensions{Version: "TLSv1.3", TLS13: true}, Valid: true},
+		{Name: "unparsed hello", Err: errors.New("no server hello found in the handshake")},
+	}
+
+	for _, v := range cases {
+		if err := checkExtendedMasterSecret(v.Negotiated, v.Err); (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+	}
+}
+
+func TestRequireEMS(t *testing.T) {
+	dir, cleanup := testDir(t, "ems")
+	defer cleanup()
+
+	ca := newTestCA(t, "EMS Test CA")
+	server := newServerCert(t, "server", ca)
+	caFile := pemFile(t, dir, "ca.pem", ca)
+
+	// both servers offer the extended master secret, or its TLS 1.3
+	// equivalent, so the connections are allowed
+	for _, version := range []uint16{tls.VersionTLS12, tls.VersionTLS13} {
+		address, closeServer := configuredTLSServer(t, &tls.Config{MinVersion: version, MaxVersion: version}, 0, server)
+		connector := localConnector(t, address, caFile, func(opts *options.ToolOptions) {
+			opts.SSLRequireEMS = true
+		})
+		if conn, err := connector.dial(address); err != nil {
+			t.Errorf("%v: error dialing: %v", protocolName(version), err)
+		} else {
+			conn.Close()
+		}
+		connector.Close()
+		closeServer()
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+// Phases passed to the error formatter besides those of a single connection
+// attempt.
+const (
+	phaseConfigure = "configure"
+	phaseSession   = "session"
+	phaseCommand   = "command"
+)
+
+// SetErrorFormatter sets a function that every error the connector returns is
+// passed through first, along with the phase it occurred in: "configure",
+// "session" for establishing a session, "command" for a command the connector
+// runs on an established session, or for a single connection attempt
+// "address", "dns", "tcp", "handshake", "hostname", "knownhost", "keepalive",
+// "linger", "buffers" or "audit". That covers the errors returned by every
+// method, including those held in a HostResult or NodeInfo.
+// The formatter's result is returned in place of the error. It must be set
+// before Configure is called.
+func (self *SSLDBConnector) SetErrorFormatter(formatter func(phase string, err error) error) {
+	self.errorFormatter = formatter
+}
+
+// phaseError passes a non-nil err through the error formatter, if one is set.
+func (self *SSLDBConnector) phaseError(phase string, err error) error {
+	if err == nil || self.errorFormatter == nil {
+		return err
+	}
+	return self.errorFormatter(phase, err)
+}
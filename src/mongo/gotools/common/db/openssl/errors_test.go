@@ -0,0 +1,65 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorFormatterPhases(t *testing.T) {
+	unreachable := closedAddr(t)
+	connector := localConnector(t, unreachable, "testdata/ca.pem", nil)
+	defer connector.Close()
+	connector.SetErrorFormatter(func(phase string, err error) error {
+		return fmt.Errorf("[%v] %v", phase, err)
+	})
+
+	verifyErr := func() error {
+		return connector.VerifyAllHosts()[unreachable].Err
+	}
+	inspectErr := func(address string) func() error {
+		return func() error {
+			_, err := connector.InspectServerChain(address)
+			return err
+		}
+	}
+	addrsErr := func() error {
+		_, _, err := connector.ConnAddrs()
+		return err
+	}
+	extensionsErr := func() error {
+		_, err := connector.ConnExtensions()
+		return err
+	}
+
+	cases := []struct {
+		Name  string
+		Call  func() error
+		Phase string
+	}{
+		{Name: "VerifyAllHosts", Call: verifyErr, Phase: failureTCP},
+		{Name: "InspectServerChain without a port", Call: inspectErr("localhost"), Phase: failureAddress},
+		{Name: "InspectServerChain", Call: inspectErr(unreachable), Phase: failureTCP},
+		{Name: "ConnAddrs", Call: addrsErr, Phase: phaseSession},
+		{Name: "ConnExtensions", Call: extensionsErr, Phase: phaseSession},
+	}
+
+	for _, v := range cases {
+		err := v.Call()
+		if err == nil {
+			t.Errorf("%v: expected an error", v.Name)
+			continue
+		}
+		if prefix := "[" + v.Phase + "] "; !strings.HasPrefix(err.Error(), prefix) {
+			t.Errorf("%v: error should be formatted for the %q phase: %v", v.Name, v.Phase, err)
+		}
+	}
+}
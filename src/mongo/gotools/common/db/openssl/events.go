@@ -0,0 +1,82 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"time"
+
+	"github.com/10gen/openssl"
+)
+
+// Outcomes of a ConnectionEvent.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// ConnectionEvent describes a single connection attempt. The details of the
+// secure channel are only filled in if the handshake completed, and the
+// failure fields only if the attempt failed.
+type ConnectionEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Host      string    `json:"host"`
+	Outcome   string    `json:"outcome"`
+
+	Protocol     string     `json:"protocol,omitempty"`
+	Cipher       string     `json:"cipher,omitempty"`
+	PeerSubject  string     `json:"peerSubject,omitempty"`
+	PeerIssuer   string     `json:"peerIssuer,omitempty"`
+	PeerSerial   string     `json:"peerSerial,omitempty"`
+	PeerNotAfter *time.Time `json:"peerNotAfter,omitempty"`
+	Verification string     `json:"verification,omitempty"`
+
+	// one of the connection attempt phases passed to the error formatter
+	FailurePhase string `json:"failurePhase,omitempty"`
+	Failure      string `json:"failure,omitempty"`
+}
+
+// SetConnectionEventSink sets a function that is passed an event for every
+// connection attempt, successful or not. It's called from the goroutine
+// dialing the connection, so it must be safe for concurrent use and should
+// return quickly. It must be set before Configure is called.
+func (self *SSLDBConnector) SetConnectionEventSink(sink func(event ConnectionEvent)) {
+	self.eventSink = sink
+}
+
+// reportAttempt records the outcome of connecting to address in the recent
+// attempts log and passes it to the event sink, if either is enabled. conn is
+// nil if the handshake didn't complete, and err is nil if the attempt
+// succeeded.
+func (self *SSLDBConnector) reportAttempt(address string, conn *openssl.Conn, phase string, err error) {
+	self.attempts.add(address, phase, err)
+	if self.eventSink == nil {
+		return
+	}
+	event := ConnectionEvent{
+		Timestamp: time.Now().UTC(),
+		Host:      address,
+		Outcome:   OutcomeSuccess,
+	}
+	if conn != nil {
+		record := newAuditRecord(address, conn)
+		event.Protocol = record.Protocol
+		event.Cipher = record.Cipher
+		event.PeerSubject = record.PeerSubject
+		event.PeerIssuer = record.PeerIssuer
+		event.PeerSerial = record.PeerSerial
+		event.PeerNotAfter = record.PeerNotAfter
+		event.Verification = record.Verification
+	}
+	if err != nil {
+		event.Outcome = OutcomeFailure
+		event.FailurePhase = phase
+		event.Failure = err.Error()
+	}
+	self.eventSink(event)
+}
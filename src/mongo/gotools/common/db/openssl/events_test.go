@@ -0,0 +1,87 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestConnectionEvents(t *testing.T) {
+	dir, cleanup := testDir(t, "events")
+	defer cleanup()
+
+	ca := newTestCA(t, "Events Test CA")
+	good, closeGood := tlsServer(t, newServerCert(t, "server", ca))
+	defer closeGood()
+	unreachable := closedAddr(t)
+
+	var mu sync.Mutex
+	events := map[string]ConnectionEvent{}
+	connector := &SSLDBConnector{}
+	connector.SetConnectionEventSink(func(event ConnectionEvent) {
+		mu.Lock()
+		events[event.Host] = event
+		mu.Unlock()
+	})
+	err := connector.Configure(options.ToolOptions{
+		Connection: &options.Connection{Host: good},
+		SSL:        &options.SSL{UseSSL: true, SSLCAFile: pemFile(t, dir, "ca.pem", ca)},
+		Auth:       &options.Auth{},
+		Kerberos:   &options.Kerberos{},
+	})
+	if err != nil {
+		t.Fatalf("Error configuring connector: %v", err)
+	}
+	defer connector.Close()
+
+	if conn, err := connector.dial(good); err != nil {
+		t.Fatalf("Error connecting: %v", err)
+	} else {
+		conn.Close()
+	}
+	if _, err := connector.dial(unreachable); err == nil {
+		t.Fatalf("Expected an error connecting to %v", unreachable)
+	}
+
+	cases := []struct {
+		Name     string
+		Address  string
+		Outcome  string
+		NotAfter bool
+	}{
+		{Name: "success", Address: good, Outcome: OutcomeSuccess, NotAfter: true},
+		// no certificate was seen, so there's no expiry rather than a zero one
+		{Name: "failure", Address: unreachable, Outcome: OutcomeFailure, NotAfter: false},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range cases {
+		event, ok := events[v.Address]
+		if !ok {
+			t.Errorf("%v: no event for %v", v.Name, v.Address)
+			continue
+		}
+		if event.Outcome != v.Outcome {
+			t.Errorf("%v: outcome is %v, expected %v", v.Name, event.Outcome, v.Outcome)
+		}
+		line, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("%v: error marshaling event: %v", v.Name, err)
+		}
+		if hasNotAfter := strings.Contains(string(line), "peerNotAfter"); hasNotAfter != v.NotAfter {
+			t.Errorf("%v: event should have peerNotAfter %v: %s", v.Name, v.NotAfter, line)
+		}
+	}
+}
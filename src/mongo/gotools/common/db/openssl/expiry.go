@@ -0,0 +1,57 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+)
+
+// expiryWarner logs a warning the first time it sees a server certificate
+// for a host that expires within the configured window.
+type expiryWarner struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	warned map[string]bool
+}
+
+func newExpiryWarner(window time.Duration) *expiryWarner {
+	return &expiryWarner{window: window, warned: map[string]bool{}}
+}
+
+// check inspects the certificate the server on conn presented.
+func (w *expiryWarner) check(host string, conn *openssl.Conn) {
+	cert, err := peerCertificate(conn)
+	if err != nil {
+		log.Logvf(log.DebugLow, "unable to inspect server certificate for %v: %v", host, err)
+		return
+	}
+	remaining := cert.NotAfter.Sub(time.Now())
+	if remaining > w.window {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.warned[host] {
+		return
+	}
+	w.warned[host] = true
+	if remaining <= 0 {
+		log.Logvf(log.Always, "WARNING: the server certificate for %v expired at %v",
+			host, cert.NotAfter.UTC().Format(time.RFC3339))
+		return
+	}
+	log.Logvf(log.Always, "WARNING: the server certificate for %v expires at %v (in %v days)",
+		host, cert.NotAfter.UTC().Format(time.RFC3339), int(remaining.Hours()/24))
+}
@@ -0,0 +1,66 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestCertExpiryWarning(t *testing.T) {
+	dir, cleanup := testDir(t, "expiry")
+	defer cleanup()
+
+	day := 24 * time.Hour
+	ca := newTestCert(t, "Expiry Test CA", x509.Certificate{
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		NotAfter:              time.Now().Add(365 * day),
+	}, nil)
+	expiring := func(name string, remaining time.Duration) *testCert {
+		return newTestCert(t, name, x509.Certificate{IPAddresses: localhost, NotAfter: time.Now().Add(remaining)}, ca)
+	}
+	inside, closeInside := tlsServer(t, expiring("inside", 5*day))
+	defer closeInside()
+	outside, closeOutside := tlsServer(t, expiring("outside", 60*day))
+	defer closeOutside()
+
+	connector := localConnector(t, inside, pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.SSLCertExpiryWarning = 30
+	})
+	defer connector.Close()
+
+	logged, restoreLog := captureLog()
+	defer restoreLog()
+	// the second connection to each server mustn't warn again
+	for _, address := range []string{inside, outside, inside, outside} {
+		conn, err := connector.dial(address)
+		if err != nil {
+			t.Fatalf("Error connecting to %v: %v", address, err)
+		}
+		conn.Close()
+	}
+	restoreLog()
+
+	output := logged.String()
+	if count := strings.Count(output, "WARNING: the server certificate for "+inside+" expires at"); count != 1 {
+		t.Errorf("Expected one warning for the certificate expiring within the window, got %v: %q", count, output)
+	}
+	if !strings.Contains(output, "(in 4 days)") {
+		t.Errorf("Expected the warning to say how many days are left: %q", output)
+	}
+	if strings.Contains(output, outside) {
+		t.Errorf("Expected no warning for the certificate expiring outside the window: %q", output)
+	}
+}
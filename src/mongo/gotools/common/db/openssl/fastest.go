@@ -0,0 +1,112 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/log"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// nodeCandidate is the result of connecting a session directly to one seed.
+type nodeCandidate struct {
+	addr    string
+	session *mgo.Session
+	latency time.Duration
+	err     error
+}
+
+// dialFastest connects a session directly to each seed in parallel and
+// returns the first one that connects and answers isMaster as a healthy node,
+// which is the fastest. The sessions that connect later are closed as they
+// do.
+func dialFastest(dialInfo *mgo.DialInfo) (*mgo.Session, error) {
+	addrs := dialInfo.Addrs
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses to connect to")
+	}
+
+	// buffered so that no dial is left blocked once a winner is returned
+	results := make(chan nodeCandidate, len(addrs))
+	for _, addr := range addrs {
+		go func(addr string) {
+			results <- dialCandidate(dialInfo, addr)
+		}(addr)
+	}
+
+	errs := make([]string, 0, len(addrs))
+	for remaining := len(addrs); remaining > 0; remaining-- {
+		candidate := <-results
+		if candidate.err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", candidate.addr, candidate.err))
+			continue
+		}
+		log.Logvf(log.DebugLow, "using fastest node %v, which connected in %v", candidate.addr, candidate.latency)
+		go closeCandidates(results, remaining-1)
+		return candidate.session, nil
+	}
+	return nil, fmt.Errorf("no healthy nodes (%v)", strings.Join(errs, "; "))
+}
+
+// closeCandidates waits for the n remaining dials to finish and closes the
+// sessions of those that connected.
+func closeCandidates(results <-chan nodeCandidate, n int) {
+	for ; n > 0; n-- {
+		if candidate := <-results; candidate.session != nil {
+			log.Logvf(log.DebugHigh, "connected to %v in %v, closing it", candidate.addr, candidate.latency)
+			candidate.session.Close()
+		}
+	}
+}
+
+// dialCandidate connects a session directly to addr and times how long that
+// and an isMaster took. A node is healthy if it's a primary or a secondary,
+// which excludes nodes that are recovering or still starting up. The session
+// is put in monotonic mode, since in the default strong mode a direct session
+// to a secondary can't run anything; as it only knows addr, allowing reads
+// from a secondary can't route them anywhere else.
+func dialCandidate(dialInfo *mgo.DialInfo, addr string) nodeCandidate {
+	candidate := nodeCandidate{addr: addr}
+	info := *dialInfo
+	info.Addrs = []string{addr}
+	info.Direct = true
+
+	start := time.Now()
+	session, err := mgo.DialWithInfo(&info)
+	if err != nil {
+		candidate.err = err
+		return candidate
+	}
+	session.SetMode(mgo.Monotonic, true)
+	result := bson.M{}
+	if err = session.Run("isMaster", &result); err != nil {
+		session.Close()
+		candidate.err = fmt.Errorf("error running isMaster: %v", err)
+		return candidate
+	}
+	candidate.latency = time.Since(start)
+	if result["ismaster"] != true && result["secondary"] != true {
+		session.Close()
+		candidate.err = fmt.Errorf("node is neither a primary nor a secondary")
+		return candidate
+	}
+	candidate.session = session
+	return candidate
+}
+
+// DirectSessions reports whether each session is connected directly to a
+// single node, which may be a secondary, as it is when selecting the fastest
+// node or pinning sessions to a member.
+func (self *SSLDBConnector) DirectSessions() bool {
+	return self.selectFastest || self.pinnedMember != ""
+}
@@ -0,0 +1,64 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2"
+)
+
+func TestDialFastestNoHealthyNodes(t *testing.T) {
+	cases := []struct {
+		Addrs []string
+		Error []string
+	}{
+		{Addrs: nil, Error: []string{"no addresses to connect to"}},
+		{Addrs: []string{closedAddr(t)}, Error: []string{"no healthy nodes"}},
+		{Addrs: []string{closedAddr(t), closedAddr(t)}, Error: []string{"no healthy nodes"}},
+	}
+
+	for _, v := range cases {
+		dialInfo := &mgo.DialInfo{Addrs: v.Addrs, Timeout: 200 * time.Millisecond}
+		session, err := dialFastest(dialInfo)
+		if err == nil {
+			session.Close()
+			t.Errorf("Expected an error dialing %v but connected", v.Addrs)
+			continue
+		}
+		for _, want := range append(v.Error, v.Addrs...) {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Error dialing %v should mention %q: %v", v.Addrs, want, err)
+			}
+		}
+	}
+}
+
+func TestDirectSessions(t *testing.T) {
+	cases := []struct {
+		Name   string
+		With   func(opts *options.ToolOptions)
+		Direct bool
+	}{
+		{Name: "default"},
+		{Name: "fastest node", With: func(opts *options.ToolOptions) { opts.SelectFastestNode = true }, Direct: true},
+		{Name: "pinned member", With: func(opts *options.ToolOptions) { opts.PinToMember = "db1.example.com" }, Direct: true},
+	}
+
+	for _, v := range cases {
+		connector := configuredConnector(t, "localhost", v.With)
+		if direct := connector.DirectSessions(); direct != v.Direct {
+			t.Errorf("%v: direct is %v, expected %v", v.Name, direct, v.Direct)
+		}
+		connector.Close()
+	}
+}
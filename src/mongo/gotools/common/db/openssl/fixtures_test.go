@@ -0,0 +1,397 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/log"
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// localhost holds the address the test servers listen on, for their
+// certificates' IP address SANs.
+var localhost = []net.IP{net.ParseIP("127.0.0.1")}
+
+// testDir creates a temporary directory for a test's files, returning it
+// and a function that removes it.
+func testDir(t *testing.T, name string) (string, func()) {
+	dir, err := ioutil.TempDir("", name)
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %v", err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// testCert is a certificate generated for a test, with its key.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert generates a certificate named name from template, signed by
+// issuer or self-signed if issuer is nil. The template's serial number,
+// subject, validity and key are filled in if unset.
+func newTestCert(t *testing.T, name string, template x509.Certificate, issuer *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	if template.SerialNumber == nil {
+		if template.SerialNumber, err = rand.Int(rand.Reader, big.NewInt(1<<62)); err != nil {
+			t.Fatalf("Error generating serial number: %v", err)
+		}
+	}
+	template.Subject = pkix.Name{CommonName: name}
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(time.Hour)
+	}
+	parent, signer := &template, key
+	if issuer != nil {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("Error creating certificate %v: %v", name, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error parsing certificate %v: %v", name, err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+// newTestCA generates a self-signed CA certificate named name.
+func newTestCA(t *testing.T, name string) *testCert {
+	return newTestCert(t, name, x509.Certificate{
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil)
+}
+
+// newCRLIssuer generates a self-signed CA certificate named name that may
+// sign CRLs, which OpenSSL requires of the issuer of a CRL when the key usage
+// is set.
+func newCRLIssuer(t *testing.T, name string) *testCert {
+	return newTestCert(t, name, x509.Certificate{
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil)
+}
+
+// newTestIntermediate generates an intermediate CA certificate named name,
+// signed by issuer.
+func newTestIntermediate(t *testing.T, name string, issuer *testCert) *testCert {
+	return newTestCert(t, name, x509.Certificate{
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, issuer)
+}
+
+// newServerCert generates a certificate named name for a local test server,
+// signed by issuer.
+func newServerCert(t *testing.T, name string, issuer *testCert) *testCert {
+	return newTestCert(t, name, x509.Certificate{IPAddresses: localhost}, issuer)
+}
+
+// chainPEM encodes certs in PEM form, in order.
+func chainPEM(certs ...*testCert) []byte {
+	var data []byte
+	for _, cert := range certs {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.cert.Raw})...)
+	}
+	return data
+}
+
+// pemFile writes the certificates to a PEM file in dir and returns its path.
+func pemFile(t *testing.T, dir, name string, certs ...*testCert) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, chainPEM(certs...), 0600); err != nil {
+		t.Fatalf("Error writing %v: %v", path, err)
+	}
+	return path
+}
+
+// pemKeyFile writes cert and its key to a PEM key file in dir and returns its
+// path.
+func pemKeyFile(t *testing.T, dir, name string, cert *testCert) string {
+	der, err := x509.MarshalECPrivateKey(cert.key)
+	if err != nil {
+		t.Fatalf("Error marshalling key: %v", err)
+	}
+	data := append(chainPEM(cert), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})...)
+	path := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Error writing %v: %v", path, err)
+	}
+	return path
+}
+
+// crlFile writes a CRL issued by ca, revoking the given certificates, to a
+// PEM file in dir and returns its path.
+func crlFile(t *testing.T, dir, name string, ca *testCert, revoked ...*testCert) string {
+	var entries []pkix.RevokedCertificate
+	for _, cert := range revoked {
+		entries = append(entries, pkix.RevokedCertificate{SerialNumber: cert.cert.SerialNumber, RevocationTime: time.Now()})
+	}
+	der, err := ca.cert.CreateCRL(rand.Reader, ca.key, entries, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Error creating CRL: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Error writing %v: %v", path, err)
+	}
+	return path
+}
+
+// closedAddr returns the address of a local port nothing listens on.
+func closedAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// tlsServer accepts ssl connections on a local port, presenting chain, the
+// first of which is the server's own certificate, and closes each connection
+// once the handshake is done. It returns the address it listens on.
+func tlsServer(t *testing.T, chain ...*testCert) (string, func()) {
+	return configuredTLSServer(t, &tls.Config{}, 0, chain...)
+}
+
+// configuredTLSServer is like tlsServer, but set up with config and, if
+// accepts is non-zero, no longer listening once it has accepted that many
+// connections.
+func configuredTLSServer(t *testing.T, config *tls.Config, accepts int, chain ...*testCert) (string, func()) {
+	certificate := tls.Certificate{PrivateKey: chain[0].key}
+	for _, cert := range chain {
+		certificate.Certificate = append(certificate.Certificate, cert.cert.Raw)
+	}
+	config = config.Clone()
+	config.Certificates = []tls.Certificate{certificate}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	go func() {
+		for accepted := 0; accepts == 0 || accepted < accepts; accepted++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+		listener.Close()
+	}()
+	return listener.Addr().String(), func() { listener.Close() }
+}
+
+// testOptions returns the options of a connector to host, trusting
+// testdata/ca.pem.
+func testOptions(host string) options.ToolOptions {
+	return options.ToolOptions{
+		Connection: &options.Connection{Host: host, Port: "27017"},
+		SSL:        &options.SSL{UseSSL: true, SSLCAFile: "testdata/ca.pem"},
+		Auth:       &options.Auth{},
+		Kerberos:   &options.Kerberos{},
+	}
+}
+
+// configuredConnector returns a connector configured with testOptions(host).
+// with can change the options first.
+func configuredConnector(t *testing.T, host string, with func(opts *options.ToolOptions)) *SSLDBConnector {
+	opts := testOptions(host)
+	if with != nil {
+		with(&opts)
+	}
+	connector := &SSLDBConnector{}
+	if err := connector.Configure(opts); err != nil {
+		t.Fatalf("Error configuring connector: %v", err)
+	}
+	return connector
+}
+
+// localConnector is like configuredConnector, but connects to the addresses,
+// which include their ports, of local test servers, trusting caFile.
+func localConnector(t *testing.T, addrs, caFile string, with func(opts *options.ToolOptions)) *SSLDBConnector {
+	return configuredConnector(t, addrs, func(opts *options.ToolOptions) {
+		opts.Port = ""
+		opts.SSLCAFile = caFile
+		if with != nil {
+			with(opts)
+		}
+	})
+}
+
+// captureLog sends the tool log to a buffer until the returned function is
+// called, which sends it back to stderr.
+func captureLog() (*bytes.Buffer, func()) {
+	var buf bytes.Buffer
+	log.SetWriter(&buf)
+	return &buf, func() { log.SetWriter(os.Stderr) }
+}
+
+// waitFor polls until ready reports true, failing the test after timeout.
+func waitFor(t *testing.T, timeout time.Duration, what string, ready func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !ready() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %v", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+const (
+	opReply = 1
+	opQuery = 2004
+)
+
+// wireReply answers a command sent to database. A nil reply is an ok reply.
+type wireReply func(database string, command bson.D) bson.M
+
+// wireServer accepts plain connections on a local port and answers the
+// commands mgo sends, as OP_QUERY messages, with reply. It returns the
+// address it listens on.
+func wireServer(t *testing.T, reply wireReply) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	go acceptWire(listener, reply)
+	return listener.Addr().String(), func() { listener.Close() }
+}
+
+// tlsWireServer is like wireServer, but accepts ssl connections presenting
+// cert.
+func tlsWireServer(t *testing.T, cert *testCert, reply wireReply) (string, func()) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert.cert.Raw}, PrivateKey: cert.key}},
+	})
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	go acceptWire(listener, reply)
+	return listener.Addr().String(), func() { listener.Close() }
+}
+
+func acceptWire(listener net.Listener, reply wireReply) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go serveWire(conn, reply)
+	}
+}
+
+func serveWire(conn net.Conn, reply wireReply) {
+	defer conn.Close()
+	for {
+		var header [16]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		length := int(binary.LittleEndian.Uint32(header[0:]))
+		requestID := binary.LittleEndian.Uint32(header[4:])
+		opCode := binary.LittleEndian.Uint32(header[12:])
+		body := make([]byte, length-len(header))
+		if _, err := io.ReadFull(conn, body); err != nil || opCode != opQuery {
+			return
+		}
+		// flags, then the collection name, skip and limit before the query
+		name := bytes.IndexByte(body[4:], 0)
+		if name < 0 {
+			return
+		}
+		database := strings.TrimSuffix(string(body[4:4+name]), ".$cmd")
+		var query bson.D
+		if err := bson.Unmarshal(body[4+name+1+8:], &query); err != nil || len(query) == 0 {
+			return
+		}
+		if query[0].Name == "$query" {
+			if wrapped, ok := query[0].Value.(bson.D); ok && len(wrapped) > 0 {
+				query = wrapped
+			}
+		}
+
+		var doc bson.M
+		if reply != nil {
+			doc = reply(database, query)
+		}
+		if doc == nil {
+			doc = bson.M{"ok": 1}
+		}
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return
+		}
+		message := make([]byte, 36, 36+len(data))
+		binary.LittleEndian.PutUint32(message[0:], uint32(36+len(data)))
+		binary.LittleEndian.PutUint32(message[8:], requestID)
+		binary.LittleEndian.PutUint32(message[12:], opReply)
+		// a single document, with no flags or cursor
+		binary.LittleEndian.PutUint32(message[32:], 1)
+		if _, err = conn.Write(append(message, data...)); err != nil {
+			return
+		}
+	}
+}
+
+// mongodReply answers isMaster as a standalone mongod that only speaks
+// OP_QUERY.
+func mongodReply(database string, command bson.D) bson.M {
+	if name := command[0].Name; name == "ismaster" || name == "isMaster" {
+		return bson.M{"ismaster": true, "maxWireVersion": 2, "ok": 1}
+	}
+	return nil
+}
+
+// secondaryReply answers isMaster as a secondary of replica set rs0, which mgo
+// only sends reads to in modes that allow secondaries, and every other command
+// with reply.
+func secondaryReply(reply wireReply) wireReply {
+	return func(database string, command bson.D) bson.M {
+		if name := command[0].Name; name == "ismaster" || name == "isMaster" {
+			return bson.M{"ismaster": false, "secondary": true, "setName": "rs0", "maxWireVersion": 4, "ok": 1}
+		}
+		return reply(database, command)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+// healthCheckTimeout returns how long a health check may wait for a server,
+// which is the connection timeout unless a shorter one was configured.
+func (self *SSLDBConnector) healthCheckTimeout() time.Duration {
+	if self.healthTimeout > 0 {
+		return self.healthTimeout
+	}
+	return self.dialInfo.Timeout
+}
+
+// Ping connects a new session to the deployment and pings it, failing once
+// the health check timeout passes without an answer. It must be called after
+// Configure.
+func (self *SSLDBConnector) Ping() error {
+	if err := dialWithin(*self.currentDialInfo(), self.healthCheckTimeout()); err != nil {
+		return self.phaseError(phaseSession, err)
+	}
+	return nil
+}
+
+// dialWithin connects a session with dialInfo and closes it again, failing
+// if that takes longer than timeout.
+func dialWithin(dialInfo mgo.DialInfo, timeout time.Duration) error {
+	dialInfo.Timeout = timeout
+	done := make(chan error, 1)
+	go func() {
+		// DialWithInfo pings the server before returning
+		session, err := mgo.DialWithInfo(&dialInfo)
+		if err == nil {
+			session.Close()
+		}
+		done <- err
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		// mgo doesn't bound the time the dialer takes, or how long it waits
+		// to sync with the servers, so a slow server could hold up the check
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-expired:
+		return fmt.Errorf("no server answered a ping within %v", timeout)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestPingHealthCheckTimeout(t *testing.T) {
+	dir, cleanup := testDir(t, "health")
+	defer cleanup()
+
+	ca := newTestCA(t, "Health Test CA")
+	server := newServerCert(t, "server", ca)
+	caFile := pemFile(t, dir, "ca.pem", ca)
+
+	// a server that completes the handshake and answers commands
+	healthy, closeHealthy := tlsWireServer(t, server, mongodReply)
+	defer closeHealthy()
+
+	// a server that accepts connections but never answers
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	defer silent.Close()
+
+	cases := []struct {
+		Name    string
+		Address string
+		Healthy bool
+	}{
+		{Name: "healthy", Address: healthy, Healthy: true},
+		{Name: "silent", Address: silent.Addr().String()},
+		{Name: "unreachable", Address: closedAddr(t)},
+	}
+
+	for _, v := range cases {
+		connector := localConnector(t, v.Address, caFile, func(opts *options.ToolOptions) {
+			opts.Timeout = 60
+			opts.HealthCheckSelectionTimeout = 500 * time.Millisecond
+		})
+		start := time.Now()
+		err := connector.Ping()
+		elapsed := time.Since(start)
+		connector.Close()
+
+		if (err == nil) != v.Healthy {
+			t.Errorf("%v: healthy is %v, expected %v: %v", v.Name, err == nil, v.Healthy, err)
+		}
+		// the check gives up long before the connection timeout
+		if elapsed > 10*time.Second {
+			t.Errorf("%v: Ping took %v with a 500ms health check timeout", v.Name, elapsed)
+		}
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Health   time.Duration
+		Expected time.Duration
+	}{
+		{Name: "default", Expected: 60 * time.Second},
+		{Name: "shorter", Health: 500 * time.Millisecond, Expected: 500 * time.Millisecond},
+	}
+
+	for _, v := range cases {
+		connector := configuredConnector(t, "localhost", func(opts *options.ToolOptions) {
+			opts.Timeout = 60
+			opts.HealthCheckSelectionTimeout = v.Health
+		})
+		if timeout := connector.healthCheckTimeout(); timeout != v.Expected {
+			t.Errorf("%v: timeout is %v, expected %v", v.Name, timeout, v.Expected)
+		}
+		connector.Close()
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/10gen/openssl"
+)
+
+// CertInfo describes one certificate of the chain a server presented.
+type CertInfo struct {
+	Subject      string
+	Issuer       string
+	SerialNumber string
+	NotBefore    time.Time
+	NotAfter     time.Time
+	DNSNames     []string
+	IPAddresses  []net.IP
+
+	// the public key algorithm, such as "RSA" or "ECDSA", and its size
+	KeyType string
+	KeyBits int
+
+	SignatureAlgorithm string
+
+	// the certificate and every certificate above it in the chain passed
+	// verification, after any relaxations the connector was configured with
+	Trusted bool
+	// the certificate appears in a configured CRL
+	Revoked bool
+	// why verification of this certificate failed, if it did
+	VerifyErrors []string
+}
+
+// InspectServerChain connects to the server at address and describes the
+// chain it presents, from the server's certificate up to the root that
+// verification reached. The chain is reported even if it doesn't verify. It
+// must be called after Configure.
+func (self *SSLDBConnector) InspectServerChain(address string) ([]CertInfo, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, self.phaseError(failureAddress, err)
+	}
+
+	// the recording callback has to be set on a context of its own, so that
+	// it only sees this connection
+	ctx, err := setupCtx(self.opts)
+	if err != nil {
+		return nil, self.phaseError(phaseConfigure, fmt.Errorf("openssl configuration: %v", err))
+	}
+	configured, err := verifyCallback(self.opts)
+	if err != nil {
+		return nil, self.phaseError(phaseConfigure, err)
+	}
+	recorder := &chainRecorder{certs: map[int]*x509.Certificate{}, errors: map[int][]openssl.VerifyResult{}}
+	ctx.SetVerify(openssl.VerifyPeer, func(ok bool, store *openssl.CertificateStoreCtx) bool {
+		if !ok && configured != nil {
+			ok = configured(ok, store)
+		}
+		recorder.record(ok, store)
+		return true
+	})
+
+	tcpConn, err := net.DialTimeout("tcp", address, self.dialInfo.Timeout)
+	if err != nil {
+		return nil, self.phaseError(failureTCP, err)
+	}
+	conn, err := openssl.Client(tcpConn, ctx)
+	if err != nil {
+		tcpConn.Close()
+		return nil, self.phaseError(failureHandshake, err)
+	}
+	defer conn.Close()
+	if err = conn.SetTlsExtHostName(host); err != nil {
+		return nil, self.phaseError(failureHandshake, err)
+	}
+	if err = conn.Handshake(); err != nil {
+		return nil, self.phaseError(failureHandshake, err)
+	}
+
+	if len(recorder.certs) == 0 {
+		// verification never ran, so describe the chain as presented
+		chain, err := conn.PeerCertificateChain()
+		if err != nil {
+			return nil, self.phaseError(failureHandshake, err)
+		}
+		for depth, cert := range chain {
+			parsed, err := toX509(cert)
+			if err != nil {
+				return nil, self.phaseError(failureHandshake, err)
+			}
+			recorder.certs[depth] = parsed
+		}
+	}
+	return recorder.describe(), nil
+}
+
+// chainRecorder collects the certificates OpenSSL verifies and the errors it
+// finds with each, keyed by depth in the chain.
+type chainRecorder struct {
+	mu     sync.Mutex
+	certs  map[int]*x509.Certificate
+	errors map[int][]openssl.VerifyResult
+}
+
+func (r *chainRecorder) record(ok bool, store *openssl.CertificateStoreCtx) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	depth := store.Depth()
+	if _, seen := r.certs[depth]; !seen {
+		if cert := store.GetCurrentCert(); cert != nil {
+			if parsed, err := toX509(cert); err == nil {
+				r.certs[depth] = parsed
+			}
+		}
+	}
+	if !ok {
+		r.errors[depth] = append(r.errors[depth], store.VerifyResult())
+	}
+}
+
+// describe returns the recorded chain, starting with the server's
+// certificate.
+func (r *chainRecorder) describe() []CertInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	infos := make([]CertInfo, 0, len(r.certs))
+	for depth := 0; r.certs[depth] != nil; depth++ {
+		infos = append(infos, newCertInfo(r.certs[depth], r.errors[depth]))
+	}
+	// a certificate is only trusted if its issuers are
+	trusted := true
+	for i := len(infos) - 1; i >= 0; i-- {
+		trusted = trusted && len(infos[i].VerifyErrors) == 0
+		infos[i].Trusted = trusted
+	}
+	return infos
+}
+
+func newCertInfo(cert *x509.Certificate, errors []openssl.VerifyResult) CertInfo {
+	info := CertInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SerialNumber:       fmt.Sprintf("%X", cert.SerialNumber),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		DNSNames:           cert.DNSNames,
+		IPAddresses:        cert.IPAddresses,
+		KeyType:            cert.PublicKeyAlgorithm.String(),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+	}
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		info.KeyBits = key.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.KeyBits = key.Curve.Params().BitSize
+	case *dsa.PublicKey:
+		info.KeyBits = key.P.BitLen()
+	}
+	for _, result := range errors {
+		if result == openssl.CertRevoked {
+			info.Revoked = true
+		}
+		info.VerifyErrors = append(info.VerifyErrors, verifyResultString(result))
+	}
+	return info
+}
+
+// verifyResultDescriptions describes the verification failures most likely to
+// be seen; the bindings don't expose X509_verify_cert_error_string.
+var verifyResultDescriptions = map[openssl.VerifyResult]string{
+	openssl.UnableToGetIssuerCert:        "unable to get issuer certificate",
+	openssl.UnableToGetCrl:               "unable to get certificate CRL",
+	openssl.CertSignatureFailure:         "certificate signature failure",
+	openssl.CertNotYetValid:              "certificate is not yet valid",
+	openssl.CertHasExpired:               "certificate has expired",
+	openssl.DepthZeroSelfSignedCert:      "self-signed certificate",
+	openssl.SelfSignedCertInChain:        "self-signed certificate in certificate chain",
+	openssl.UnableToGetIssuerCertLocally: "unable to get local issuer certificate",
+	openssl.UnableToVerifyLeafSignature:  "unable to verify the first certificate",
+	openssl.CertChainTooLong:             "certificate chain too long",
+	openssl.CertRevoked:                  "certificate revoked",
+	openssl.InvalidCa:                    "invalid CA certificate",
+	openssl.PathLengthExceeded:           "path length constraint exceeded",
+	openssl.InvalidPurpose:               "unsupported certificate purpose",
+	openssl.CertUntrusted:                "certificate not trusted",
+	openssl.CertRejected:                 "certificate rejected",
+	openssl.KeyusageNoCertsign:           "key usage does not include certificate signing",
+}
+
+func verifyResultString(result openssl.VerifyResult) string {
+	if description, ok := verifyResultDescriptions[result]; ok {
+		return description
+	}
+	return fmt.Sprintf("verify error %d", int(result))
+}
@@ -0,0 +1,83 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"fmt"
+	"testing"
+)
+
+func TestInspectServerChain(t *testing.T) {
+	dir, cleanup := testDir(t, "inspect")
+	defer cleanup()
+
+	root := newTestCA(t, "Inspect Root CA")
+	intermediate := newTestIntermediate(t, "Inspect Intermediate CA", root)
+	leaf := newTestCert(t, "server", x509.Certificate{DNSNames: []string{"localhost"}, IPAddresses: localhost}, intermediate)
+	trusted, closeTrusted := tlsServer(t, leaf, intermediate)
+	defer closeTrusted()
+	untrustedLeaf := newServerCert(t, "untrusted", newTestCA(t, "Untrusted CA"))
+	untrusted, closeUntrusted := tlsServer(t, untrustedLeaf)
+	defer closeUntrusted()
+
+	connector := localConnector(t, trusted, pemFile(t, dir, "ca.pem", root), nil)
+	defer connector.Close()
+
+	chain, err := connector.InspectServerChain(trusted)
+	if err != nil {
+		t.Fatalf("Error inspecting a trusted chain: %v", err)
+	}
+	expected := []*testCert{leaf, intermediate, root}
+	if len(chain) != len(expected) {
+		t.Fatalf("Chain has %v certificates, expected %v: %+v", len(chain), len(expected), chain)
+	}
+	for i, info := range chain {
+		cert := expected[i].cert
+		if info.Subject != cert.Subject.String() || info.Issuer != cert.Issuer.String() {
+			t.Errorf("Certificate %v is %v issued by %v, expected %v issued by %v",
+				i, info.Subject, info.Issuer, cert.Subject, cert.Issuer)
+		}
+		if info.SerialNumber != fmt.Sprintf("%X", cert.SerialNumber) {
+			t.Errorf("Certificate %v has serial number %v, expected %X", i, info.SerialNumber, cert.SerialNumber)
+		}
+		if info.KeyType != "ECDSA" || info.KeyBits != 256 {
+			t.Errorf("Certificate %v has a %v bit %v key, expected a 256 bit ECDSA key", i, info.KeyBits, info.KeyType)
+		}
+		if !info.Trusted || info.Revoked || len(info.VerifyErrors) != 0 {
+			t.Errorf("Certificate %v should be trusted: %+v", i, info)
+		}
+	}
+	if len(chain[0].DNSNames) != 1 || len(chain[0].IPAddresses) != 1 {
+		t.Errorf("Server certificate SANs are %v and %v", chain[0].DNSNames, chain[0].IPAddresses)
+	}
+
+	chain, err = connector.InspectServerChain(untrusted)
+	if err != nil {
+		t.Fatalf("Error inspecting an untrusted chain: %v", err)
+	}
+	if len(chain) == 0 {
+		t.Fatalf("Expected the untrusted chain to be described")
+	}
+	if chain[0].Subject != untrustedLeaf.cert.Subject.String() {
+		t.Errorf("Server certificate is %v, expected %v", chain[0].Subject, untrustedLeaf.cert.Subject)
+	}
+	for i, info := range chain {
+		if info.Trusted {
+			t.Errorf("Certificate %v of the untrusted chain is trusted: %+v", i, info)
+		}
+	}
+	if len(chain[len(chain)-1].VerifyErrors) == 0 {
+		t.Errorf("Expected the top of the untrusted chain to have verify errors: %+v", chain)
+	}
+
+	if _, err := connector.InspectServerChain(closedAddr(t)); err == nil {
+		t.Errorf("Expected an error inspecting an unreachable server")
+	}
+}
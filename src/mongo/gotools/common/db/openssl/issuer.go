@@ -0,0 +1,52 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/10gen/openssl"
+)
+
+// clientCertificate returns our own certificate, the first one in the PEM key
+// file.
+func clientCertificate(pemKeyFile string) (*x509.Certificate, error) {
+	pemBytes, err := ioutil.ReadFile(pemKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading PEM key file: %v", err)
+	}
+	chain, err := parseChain(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error reading PEM key file: %v", err)
+	}
+	return chain[0], nil
+}
+
+// checkSameIssuer returns an error unless the server's certificate on conn
+// was issued by the same CA as client: both must name the same issuer and,
+// if both identify the key that signed them, the same signing key.
+func checkSameIssuer(conn *openssl.Conn, client *x509.Certificate) error {
+	server, err := peerCertificate(conn)
+	if err != nil {
+		return fmt.Errorf("error getting the server's certificate: %v", err)
+	}
+	if !bytes.Equal(server.RawIssuer, client.RawIssuer) {
+		return fmt.Errorf("server certificate is issued by '%v', but the client certificate is issued by '%v'",
+			server.Issuer, client.Issuer)
+	}
+	if len(server.AuthorityKeyId) > 0 && len(client.AuthorityKeyId) > 0 &&
+		!bytes.Equal(server.AuthorityKeyId, client.AuthorityKeyId) {
+		return fmt.Errorf("server and client certificates are both issued by '%v', but with different keys",
+			server.Issuer)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestRequireSameIssuer(t *testing.T) {
+	dir, cleanup := testDir(t, "issuer")
+	defer cleanup()
+
+	ca := newTestCA(t, "Issuer Test CA")
+	// another CA with the same name but its own key
+	impostor := newTestCA(t, "Issuer Test CA")
+	other := newTestCA(t, "Other Issuer Test CA")
+	sameCA, closeSameCA := tlsServer(t, newServerCert(t, "same CA", ca))
+	defer closeSameCA()
+	sameName, closeSameName := tlsServer(t, newServerCert(t, "same name", impostor))
+	defer closeSameName()
+	otherCA, closeOtherCA := tlsServer(t, newServerCert(t, "other CA", other))
+	defer closeOtherCA()
+
+	client := newTestCert(t, "client", x509.Certificate{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, ca)
+	connector := localConnector(t, sameCA, pemFile(t, dir, "ca.pem", ca, impostor, other), func(opts *options.ToolOptions) {
+		opts.SSLPEMKeyFile = pemKeyFile(t, dir, "client.pem", client)
+		opts.SSLRequireSameIssuer = true
+	})
+	defer connector.Close()
+
+	cases := []struct {
+		Name    string
+		Address string
+		Error   string
+	}{
+		{Name: "same CA", Address: sameCA},
+		{Name: "same issuer name with another key", Address: sameName, Error: "different keys"},
+		{Name: "other CA", Address: otherCA, Error: "Other Issuer Test CA"},
+	}
+
+	for _, v := range cases {
+		conn, err := connector.dial(v.Address)
+		if err == nil {
+			conn.Close()
+		}
+		if v.Error == "" {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", v.Name, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), v.Error) {
+			t.Errorf("%v: error should mention %q: %v", v.Name, v.Error, err)
+		}
+	}
+}
+
+func TestRequireSameIssuerNeedsPEMKeyFile(t *testing.T) {
+	opts := testOptions("localhost")
+	opts.SSLRequireSameIssuer = true
+	if err := (&SSLDBConnector{}).Configure(opts); err == nil {
+		t.Errorf("Expected --sslRequireSameIssuer to require --sslPEMKeyFile")
+	}
+}
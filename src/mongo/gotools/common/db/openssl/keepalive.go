@@ -0,0 +1,72 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/log"
+	"gopkg.in/mgo.v2"
+)
+
+// sessionPinger pings a session the connector holds on to at an interval,
+// so that its connection isn't closed by the server or something in between
+// for being idle.
+type sessionPinger struct {
+	session  *mgo.Session
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newSessionPinger starts pinging session every interval.
+func newSessionPinger(session *mgo.Session, interval time.Duration) *sessionPinger {
+	p := &sessionPinger{
+		session:  session,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *sessionPinger) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if err := p.session.Ping(); err != nil {
+				// a session keeps failing on a dead connection until it's
+				// refreshed, after which it connects again when next used
+				log.Logvf(log.DebugLow, "error pinging idle session, refreshing it: %v", err)
+				p.session.Refresh()
+			}
+		}
+	}
+}
+
+// close stops pinging and waits for a ping in progress to finish.
+func (p *sessionPinger) close() {
+	close(p.stop)
+	<-p.done
+}
+
+// standbyInterval returns how often the warm standby session is pinged.
+func (self *SSLDBConnector) standbyInterval() time.Duration {
+	if self.appKeepAlive > 0 {
+		return self.appKeepAlive
+	}
+	return standbyCheckInterval
+}
@@ -0,0 +1,125 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestSessionPinger(t *testing.T) {
+	var pings int32
+	address, closeServer := wireServer(t, func(database string, command bson.D) bson.M {
+		if command[0].Name == "ping" {
+			atomic.AddInt32(&pings, 1)
+		}
+		return mongodReply(database, command)
+	})
+	defer closeServer()
+
+	session, err := mgo.DialWithInfo(&mgo.DialInfo{Addrs: []string{address}, Direct: true, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Error dialing: %v", err)
+	}
+	defer session.Close()
+
+	pinger := newSessionPinger(session, 20*time.Millisecond)
+	waitFor(t, 5*time.Second, "the session to be pinged", func() bool { return atomic.LoadInt32(&pings) >= 2 })
+	pinger.close()
+
+	// close waits for a ping in progress, so none come after it returns
+	stopped := atomic.LoadInt32(&pings)
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&pings); n != stopped {
+		t.Errorf("Pinged %v times after the pinger was closed", n-stopped)
+	}
+}
+
+func TestAppKeepAliveInterval(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Interval time.Duration
+		Share    bool
+		Standby  bool
+		Valid    bool
+		Expected time.Duration
+	}{
+		{Name: "unset", Valid: true, Expected: standbyCheckInterval},
+		{Name: "shared sessions", Interval: time.Minute, Share: true, Valid: true, Expected: time.Minute},
+		{Name: "warm standby", Interval: time.Minute, Standby: true, Valid: true, Expected: time.Minute},
+		{Name: "nothing to keep alive", Interval: time.Minute},
+		{Name: "negative", Interval: -time.Minute, Share: true},
+	}
+
+	// nothing listens, so closing a warm standby doesn't wait long for its dial
+	address := closedAddr(t)
+	for _, v := range cases {
+		opts := testOptions(address)
+		opts.Port = ""
+		opts.Timeout = 1
+		opts.ShareSessions = v.Share
+		opts.WarmStandby = v.Standby
+		opts.AppKeepAliveInterval = v.Interval
+		connector := &SSLDBConnector{}
+		err := connector.Configure(opts)
+		if valid := err == nil; valid != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, valid, v.Valid, err)
+			continue
+		}
+		if !v.Valid {
+			continue
+		}
+		if interval := connector.standbyInterval(); interval != v.Expected {
+			t.Errorf("%v: standby interval is %v, expected %v", v.Name, interval, v.Expected)
+		}
+		connector.Close()
+	}
+}
+
+func TestSharedSessionPinger(t *testing.T) {
+	var pings int32
+	address, closeServer := wireServer(t, func(database string, command bson.D) bson.M {
+		if command[0].Name == "ping" {
+			atomic.AddInt32(&pings, 1)
+		}
+		return mongodReply(database, command)
+	})
+	defer closeServer()
+
+	dial := func() (*mgo.Session, error) {
+		return mgo.DialWithInfo(&mgo.DialInfo{Addrs: []string{address}, Direct: true, Timeout: 5 * time.Second})
+	}
+	first, err := acquireSharedSession("keepalive", 20*time.Millisecond, dial)
+	if err != nil {
+		t.Fatalf("Error acquiring shared session: %v", err)
+	}
+	second, err := acquireSharedSession("keepalive", 20*time.Millisecond, dial)
+	if err != nil {
+		t.Fatalf("Error acquiring shared session: %v", err)
+	}
+	waitFor(t, 5*time.Second, "the shared session to be pinged", func() bool { return atomic.LoadInt32(&pings) >= 2 })
+
+	// the pinger runs until the last reference is released
+	releaseSharedSession(first)
+	select {
+	case <-second.pinger.done:
+		t.Fatalf("Expected the pinger to run while the session is still held")
+	default:
+	}
+	releaseSharedSession(second)
+	select {
+	case <-second.pinger.done:
+	default:
+		t.Errorf("Expected the pinger to stop once the session was released")
+	}
+}
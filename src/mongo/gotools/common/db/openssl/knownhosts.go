@@ -0,0 +1,104 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+)
+
+// knownHosts pins each server address to the key it presented the first time
+// it was connected to. The file has one "<host:port> SHA256:<fingerprint>"
+// line per server, in the style of an ssh known_hosts file, where the
+// fingerprint is the base64 SHA-256 of the certificate's public key.
+type knownHosts struct {
+	path string
+
+	mu           sync.Mutex
+	fingerprints map[string]string
+}
+
+// loadKnownHosts reads the known hosts file at path. A missing file has no
+// known hosts, and is created when the first one is recorded.
+func loadKnownHosts(path string) (*knownHosts, error) {
+	k := &knownHosts{path: path, fingerprints: map[string]string{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading known hosts file: %v", err)
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "SHA256:") {
+			return nil, fmt.Errorf("invalid entry on line %v of known hosts file %v", i+1, path)
+		}
+		k.fingerprints[fields[0]] = fields[1]
+	}
+	return k, nil
+}
+
+// spkiFingerprint returns the fingerprint of cert's public key as recorded in
+// the known hosts file.
+func spkiFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// check fails if address is known and the key the server presented on conn
+// isn't the recorded one. The key of an unknown address is recorded and
+// trusted.
+func (k *knownHosts) check(address string, conn *openssl.Conn) error {
+	cert, err := peerCertificate(conn)
+	if err != nil {
+		return fmt.Errorf("error getting the server's certificate: %v", err)
+	}
+	fingerprint := spkiFingerprint(cert)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if recorded, ok := k.fingerprints[address]; ok {
+		if recorded == fingerprint {
+			return nil
+		}
+		log.Logvf(log.Always, "WARNING: the key presented by %v has changed since it was recorded in %v; "+
+			"the server may have been reinstalled or the connection may be intercepted", address, k.path)
+		return fmt.Errorf("key %v presented by %v does not match the key %v recorded in %v",
+			fingerprint, address, recorded, k.path)
+	}
+
+	file, err := os.OpenFile(k.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening known hosts file: %v", err)
+	}
+	if _, err = fmt.Fprintf(file, "%v %v\n", address, fingerprint); err != nil {
+		file.Close()
+		return fmt.Errorf("error recording %v in known hosts file: %v", address, err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("error recording %v in known hosts file: %v", address, err)
+	}
+	k.fingerprints[address] = fingerprint
+	log.Logvf(log.Always, "trusting %v on first use, recorded its key %v in %v", address, fingerprint, k.path)
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKnownHosts(t *testing.T) {
+	dir, cleanup := testDir(t, "knownhosts")
+	defer cleanup()
+
+	cases := []struct {
+		Name     string
+		Contents *string
+		Expected map[string]string
+		Valid    bool
+	}{
+		{Name: "missing", Expected: map[string]string{}, Valid: true},
+		{Name: "empty", Contents: strPtr(""), Expected: map[string]string{}, Valid: true},
+		{
+			Name:     "entries",
+			Contents: strPtr("# recorded keys\n\ndb1:27017 SHA256:abc\n  db2:27017   SHA256:def  \n"),
+			Expected: map[string]string{"db1:27017": "SHA256:abc", "db2:27017": "SHA256:def"},
+			Valid:    true,
+		},
+		{Name: "missing fingerprint", Contents: strPtr("db1:27017\n")},
+		{Name: "unknown hash", Contents: strPtr("db1:27017 MD5:abc\n")},
+		{Name: "extra field", Contents: strPtr("db1:27017 SHA256:abc comment\n")},
+	}
+
+	for _, v := range cases {
+		path := filepath.Join(dir, v.Name)
+		if v.Contents != nil {
+			if err := ioutil.WriteFile(path, []byte(*v.Contents), 0600); err != nil {
+				t.Fatalf("Error writing %v: %v", path, err)
+			}
+		}
+		k, err := loadKnownHosts(path)
+		if !v.Valid {
+			if err == nil {
+				t.Errorf("%v: expected an error but the file was loaded", v.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: error loading: %v", v.Name, err)
+			continue
+		}
+		if len(k.fingerprints) != len(v.Expected) {
+			t.Errorf("%v: loaded %v, expected %v", v.Name, k.fingerprints, v.Expected)
+		}
+		for address, fingerprint := range v.Expected {
+			if k.fingerprints[address] != fingerprint {
+				t.Errorf("%v: fingerprint of %v is %q, expected %q", v.Name, address, k.fingerprints[address], fingerprint)
+			}
+		}
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,94 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/mongodb/mongo-tools/common/log"
+	"github.com/mongodb/mongo-tools/common/util"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// messageSizeLimitConn fails reads once the server sends a wire protocol
+// message longer than limit bytes, rather than letting mgo buffer it. Every
+// message starts with its total length as a little-endian int32.
+type messageSizeLimitConn struct {
+	net.Conn
+	limit int
+
+	// the length header of the next message, as read so far
+	header    [4]byte
+	headerLen int
+	// bytes of the current message not yet read, after its header
+	remaining int
+	err       error
+}
+
+func (c *messageSizeLimitConn) Read(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.Conn.Read(b)
+	for data := b[:n]; len(data) > 0; {
+		if c.remaining > 0 {
+			consumed := c.remaining
+			if consumed > len(data) {
+				consumed = len(data)
+			}
+			c.remaining -= consumed
+			data = data[consumed:]
+			continue
+		}
+		copied := copy(c.header[c.headerLen:], data)
+		c.headerLen += copied
+		data = data[copied:]
+		if c.headerLen < len(c.header) {
+			break
+		}
+		c.headerLen = 0
+		length := int(int32(binary.LittleEndian.Uint32(c.header[:])))
+		if length > c.limit || length < len(c.header) {
+			c.err = fmt.Errorf("server sent a message of %v bytes, larger than the %v byte limit",
+				length, c.limit)
+			c.Conn.Close()
+			return 0, c.err
+		}
+		c.remaining = length - len(c.header)
+	}
+	return n, err
+}
+
+// checkMaxMessageSize logs a warning if the server reports that it never
+// sends messages longer than limit bytes, so the limit will never apply. The
+// server's maximum is read from isMaster on a monotonic copy of session, which
+// a secondary can answer.
+func checkMaxMessageSize(session *mgo.Session, limit int) error {
+	monotonic := session.Copy()
+	defer monotonic.Close()
+	monotonic.SetMode(mgo.Monotonic, true)
+
+	result := bson.M{}
+	if err := monotonic.Run("isMaster", &result); err != nil {
+		return fmt.Errorf("error running isMaster: %v", err)
+	}
+	serverMax, err := util.ToInt(result["maxMessageSizeBytes"])
+	if err != nil {
+		// servers too old to report a limit use 48000000 bytes
+		serverMax = 48000000
+	}
+	if serverMax <= limit {
+		log.Logvf(log.Always, "WARNING: the message size limit of %v bytes is no smaller than the server's "+
+			"maximum of %v bytes, so it will never apply", limit, serverMax)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2/bson"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+)
+
+// readerConn is a connection whose reads come from a reader, in chunks of at
+// most chunk bytes.
+type readerConn struct {
+	net.Conn
+	r      io.Reader
+	chunk  int
+	closed bool
+}
+
+func (c *readerConn) Read(b []byte) (int, error) {
+	if len(b) > c.chunk {
+		b = b[:c.chunk]
+	}
+	return c.r.Read(b)
+}
+
+func (c *readerConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// wireMessages returns messages of each of the given total lengths, each
+// starting with its length header.
+func wireMessages(lengths ...int) []byte {
+	var data []byte
+	for _, length := range lengths {
+		message := make([]byte, length)
+		binary.LittleEndian.PutUint32(message, uint32(length))
+		data = append(data, message...)
+	}
+	return data
+}
+
+func TestMessageSizeLimitConn(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Data    []byte
+		Limit   int
+		Chunk   int
+		Allowed bool
+	}{
+		{Name: "messages within the limit", Data: wireMessages(16, 100, 16), Limit: 100, Chunk: 1024, Allowed: true},
+		{Name: "headers split across reads", Data: wireMessages(16, 100, 16), Limit: 100, Chunk: 3, Allowed: true},
+		{Name: "message over the limit", Data: wireMessages(16, 101), Limit: 100, Chunk: 1024},
+		{Name: "message over the limit in small reads", Data: wireMessages(16, 101), Limit: 100, Chunk: 3},
+		{Name: "length shorter than its header", Data: []byte{2, 0, 0, 0}, Limit: 100, Chunk: 1024},
+		{Name: "negative length", Data: []byte{0xff, 0xff, 0xff, 0xff}, Limit: 100, Chunk: 1024},
+	}
+
+	for _, v := range cases {
+		under := &readerConn{r: bytes.NewReader(v.Data), chunk: v.Chunk}
+		conn := &messageSizeLimitConn{Conn: under, limit: v.Limit}
+		read, err := ioutil.ReadAll(conn)
+		if v.Allowed {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", v.Name, err)
+			} else if !bytes.Equal(read, v.Data) {
+				t.Errorf("%v: read %v bytes, expected %v", v.Name, len(read), len(v.Data))
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%v: expected an error", v.Name)
+		}
+		if !under.closed {
+			t.Errorf("%v: connection not closed", v.Name)
+		}
+		if _, err := conn.Read(make([]byte, 1)); err == nil {
+			t.Errorf("%v: expected reads after the error to fail", v.Name)
+		}
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	dir, cleanup := testDir(t, "message_size")
+	defer cleanup()
+
+	ca := newTestCA(t, "Message Size Test CA")
+	// a server with the default maximum that answers "large" with a reply of
+	// about 4KB
+	address, closeServer := tlsWireServer(t, newServerCert(t, "server", ca), func(database string, command bson.D) bson.M {
+		if command[0].Name == "large" {
+			return bson.M{"data": strings.Repeat("x", 4096), "ok": 1}
+		}
+		return mongodReply(database, command)
+	})
+	defer closeServer()
+	caFile := pemFile(t, dir, "ca.pem", ca)
+
+	cases := []struct {
+		Name  string
+		Limit int
+		Valid bool
+	}{
+		{Name: "limit below the server's maximum and the reply", Limit: 2048},
+		{Name: "limit below the server's maximum but above the reply", Limit: 8192, Valid: true},
+		{Name: "limit above the server's maximum", Limit: 64000000, Valid: true},
+	}
+
+	for _, v := range cases {
+		connector := localConnector(t, address, caFile, func(opts *options.ToolOptions) {
+			opts.Timeout = 5
+			opts.MaxMessageSizeBytes = v.Limit
+		})
+		session, err := connector.GetNewSession()
+		if err != nil {
+			t.Errorf("%v: error getting a session: %v", v.Name, err)
+			connector.Close()
+			continue
+		}
+		err = session.Run("large", &bson.M{})
+		if (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+		session.Close()
+		connector.Close()
+	}
+}
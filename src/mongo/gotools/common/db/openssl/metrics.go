@@ -0,0 +1,160 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"sync"
+	"time"
+)
+
+// Reasons a connection attempt can fail, as reported in
+// ConnectionMetrics.Failures and passed to the error formatter.
+const (
+	failureAddress   = "address"
+	failureDNS       = "dns"
+	failureTCP       = "tcp"
+	failureHandshake = "handshake"
+	failureHostname  = "hostname"
+	failureKnownHost = "knownhost"
+	failureKeepAlive = "keepalive"
+	failureLinger    = "linger"
+	failureBuffers   = "buffers"
+	failureAudit     = "audit"
+)
+
+// handshakeBuckets are the upper bounds, in seconds, of the handshake latency
+// histogram. They match the Prometheus client's default buckets.
+var handshakeBuckets = [...]float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// ConnectionMetrics is a snapshot of the connections a connector has made.
+// The fields map directly onto Prometheus counters and a histogram, so an
+// exporter can publish them with const metrics without any bookkeeping.
+type ConnectionMetrics struct {
+	// connection attempts, including those made by mgo in the background
+	Attempts uint64
+	// attempts that produced a usable connection
+	Successes uint64
+	// failed attempts, keyed by the phase that failed: "address", "dns",
+	// "tcp", "handshake", "hostname", "knownhost", "keepalive", "linger",
+	// "buffers" or "audit"
+	Failures map[string]uint64
+
+	// the number and total duration in seconds of successful handshakes
+	HandshakeCount   uint64
+	HandshakeSeconds float64
+	// cumulative count of successful handshakes keyed by the upper bound of
+	// each bucket in seconds, from 5ms to 10s
+	HandshakeBuckets map[float64]uint64
+}
+
+// connectionMetrics accumulates the counts behind ConnectionMetrics. The
+// zero value is ready to use.
+type connectionMetrics struct {
+	mu               sync.Mutex
+	attempts         uint64
+	successes        uint64
+	failures         map[string]uint64
+	handshakeSeconds float64
+	// count per bucket, with one extra for handshakes above the largest
+	bucketCounts [len(handshakeBuckets) + 1]uint64
+}
+
+func (m *connectionMetrics) attempt() {
+	m.mu.Lock()
+	m.attempts++
+	m.mu.Unlock()
+}
+
+func (m *connectionMetrics) failure(reason string) {
+	m.mu.Lock()
+	if m.failures == nil {
+		m.failures = map[string]uint64{}
+	}
+	m.failures[reason]++
+	m.mu.Unlock()
+}
+
+func (m *connectionMetrics) success(handshake time.Duration) {
+	seconds := handshake.Seconds()
+	bucket := len(handshakeBuckets)
+	for i, bound := range handshakeBuckets {
+		if seconds <= bound {
+			bucket = i
+			break
+		}
+	}
+	m.mu.Lock()
+	m.successes++
+	m.handshakeSeconds += seconds
+	m.bucketCounts[bucket]++
+	m.mu.Unlock()
+}
+
+// Metrics returns a snapshot of the connections made by this connector since
+// it was configured. It is safe to call concurrently with connecting.
+func (self *SSLDBConnector) Metrics() ConnectionMetrics {
+	m := &self.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := ConnectionMetrics{
+		Attempts:         m.attempts,
+		Successes:        m.successes,
+		Failures:         make(map[string]uint64, len(m.failures)),
+		HandshakeCount:   m.successes,
+		HandshakeSeconds: m.handshakeSeconds,
+		HandshakeBuckets: make(map[float64]uint64, len(handshakeBuckets)),
+	}
+	for reason, count := range m.failures {
+		snapshot.Failures[reason] = count
+	}
+	var cumulative uint64
+	for i, bound := range handshakeBuckets {
+		cumulative += m.bucketCounts[i]
+		snapshot.HandshakeBuckets[bound] = cumulative
+	}
+	return snapshot
+}
+
+// Names of the metrics passed to a MetricsSink.
+const (
+	metricAttempts  = "mongodb_tools_connection_attempts_total"
+	metricSuccesses = "mongodb_tools_connection_successes_total"
+	metricFailures  = "mongodb_tools_connection_failures_total"
+	metricHandshake = "mongodb_tools_handshake_duration_seconds"
+)
+
+// MetricsSink receives a connector's metrics in the shape Prometheus const
+// metrics take, so that the connector doesn't depend on the Prometheus
+// client. A prometheus.Collector adapts it by implementing Counter with
+// prometheus.MustNewConstMetric and a CounterValue, and Histogram with
+// prometheus.MustNewConstHistogram, both sending to the channel passed to
+// Collect, and calling CollectMetrics from Collect. Label names are the same
+// on every call for a given metric name.
+type MetricsSink interface {
+	Counter(name, help string, labels map[string]string, value float64)
+	Histogram(name, help string, count uint64, sum float64, buckets map[float64]uint64)
+}
+
+// CollectMetrics passes a snapshot of the connector's metrics to sink: the
+// attempt, success and failure counters, with failures labeled by the phase
+// that failed, and the handshake latency histogram. It is safe to call
+// concurrently with connecting.
+func (self *SSLDBConnector) CollectMetrics(sink MetricsSink) {
+	metrics := self.Metrics()
+	sink.Counter(metricAttempts, "Connection attempts, including those made by the driver in the background.",
+		nil, float64(metrics.Attempts))
+	sink.Counter(metricSuccesses, "Connection attempts that produced a usable connection.",
+		nil, float64(metrics.Successes))
+	for phase, count := range metrics.Failures {
+		sink.Counter(metricFailures, "Failed connection attempts, by the phase that failed.",
+			map[string]string{"phase": phase}, float64(count))
+	}
+	sink.Histogram(metricHandshake, "Duration of successful SSL handshakes.",
+		metrics.HandshakeCount, metrics.HandshakeSeconds, metrics.HandshakeBuckets)
+}
@@ -0,0 +1,126 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetricsHistogram(t *testing.T) {
+	connector := &SSLDBConnector{}
+	for _, handshake := range []time.Duration{time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond, time.Minute} {
+		connector.metrics.attempt()
+		connector.metrics.success(handshake)
+	}
+	connector.metrics.attempt()
+	connector.metrics.failure(failureTCP)
+
+	metrics := connector.Metrics()
+	if metrics.Attempts != 5 || metrics.Successes != 4 || metrics.HandshakeCount != 4 {
+		t.Errorf("Counts are wrong: %+v", metrics)
+	}
+	if metrics.Failures[failureTCP] != 1 || len(metrics.Failures) != 1 {
+		t.Errorf("Failures are %v, expected one %v failure", metrics.Failures, failureTCP)
+	}
+	// buckets are cumulative, and a handshake above the largest bound is only
+	// counted in the total
+	buckets := map[float64]uint64{.005: 1, .01: 1, .025: 3, 5: 3, 10: 3}
+	for bound, count := range buckets {
+		if metrics.HandshakeBuckets[bound] != count {
+			t.Errorf("Bucket %v has %v handshakes, expected %v", bound, metrics.HandshakeBuckets[bound], count)
+		}
+	}
+	if len(metrics.HandshakeBuckets) != len(handshakeBuckets) {
+		t.Errorf("There are %v buckets, expected %v", len(metrics.HandshakeBuckets), len(handshakeBuckets))
+	}
+}
+
+func TestMetricsConnections(t *testing.T) {
+	dir, cleanup := testDir(t, "metrics")
+	defer cleanup()
+
+	ca := newTestCA(t, "Metrics Test CA")
+	good, closeGood := tlsServer(t, newServerCert(t, "good", ca))
+	defer closeGood()
+	untrusted, closeUntrusted := tlsServer(t, newServerCert(t, "untrusted", newTestCA(t, "Untrusted CA")))
+	defer closeUntrusted()
+
+	connector := localConnector(t, good, pemFile(t, dir, "ca.pem", ca), nil)
+	defer connector.Close()
+
+	for _, address := range []string{good, untrusted, closedAddr(t), "localhost"} {
+		if conn, err := connector.dial(address); err == nil {
+			conn.Close()
+		}
+	}
+
+	metrics := connector.Metrics()
+	if metrics.Attempts != 4 || metrics.Successes != 1 {
+		t.Errorf("Counts are wrong: %+v", metrics)
+	}
+	for _, phase := range []string{failureHandshake, failureTCP, failureAddress} {
+		if metrics.Failures[phase] != 1 {
+			t.Errorf("Expected one %v failure: %v", phase, metrics.Failures)
+		}
+	}
+}
+
+// recordingSink is a MetricsSink keeping what it was passed, keyed by metric
+// name and, for counters, the phase label.
+type recordingSink struct {
+	counters   map[string]float64
+	histograms map[string]ConnectionMetrics
+}
+
+func (s *recordingSink) Counter(name, help string, labels map[string]string, value float64) {
+	if phase, ok := labels["phase"]; ok {
+		name += "/" + phase
+	}
+	s.counters[name] = value
+}
+
+func (s *recordingSink) Histogram(name, help string, count uint64, sum float64, buckets map[float64]uint64) {
+	s.histograms[name] = ConnectionMetrics{HandshakeCount: count, HandshakeSeconds: sum, HandshakeBuckets: buckets}
+}
+
+func TestCollectMetrics(t *testing.T) {
+	connector := &SSLDBConnector{}
+	connector.metrics.attempt()
+	connector.metrics.success(20 * time.Millisecond)
+	connector.metrics.attempt()
+	connector.metrics.failure(failureTCP)
+	connector.metrics.attempt()
+	connector.metrics.failure(failureHandshake)
+
+	sink := &recordingSink{counters: map[string]float64{}, histograms: map[string]ConnectionMetrics{}}
+	connector.CollectMetrics(sink)
+
+	counters := map[string]float64{
+		metricAttempts:                          3,
+		metricSuccesses:                         1,
+		metricFailures + "/" + failureTCP:       1,
+		metricFailures + "/" + failureHandshake: 1,
+	}
+	for name, value := range counters {
+		if sink.counters[name] != value {
+			t.Errorf("Counter %v is %v, expected %v", name, sink.counters[name], value)
+		}
+	}
+	if len(sink.counters) != len(counters) {
+		t.Errorf("Counters are %v, expected %v", sink.counters, counters)
+	}
+	handshake, ok := sink.histograms[metricHandshake]
+	if !ok {
+		t.Fatalf("Expected the %v histogram: %v", metricHandshake, sink.histograms)
+	}
+	if handshake.HandshakeCount != 1 || handshake.HandshakeBuckets[.025] != 1 || handshake.HandshakeBuckets[.01] != 0 {
+		t.Errorf("Handshake histogram is wrong: %+v", handshake)
+	}
+}
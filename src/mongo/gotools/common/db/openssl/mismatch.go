@@ -0,0 +1,91 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+// defaultCipherList is used unless --sslCipherList is set or strict mode
+// selects its own list.
+//
+// HIGH - Enable strong ciphers
+// !EXPORT - Disable export ciphers (40/56 bit)
+// !aNULL - Disable anonymous auth ciphers
+// @STRENGTH - Sort ciphers based on strength
+const defaultCipherList = "HIGH:!EXPORT:!aNULL@STRENGTH"
+
+// cipherMismatchErrors and protocolMismatchErrors are fragments of the
+// OpenSSL errors for a handshake that failed because the client and server
+// have no cipher or protocol version in common. Most servers send a plain
+// handshake failure alert when no cipher is shared, but they send it for
+// other failures too, such as a missing client certificate, so it isn't
+// taken to mean a mismatch.
+var (
+	cipherMismatchErrors   = []string{"no shared cipher", "no ciphers available"}
+	protocolMismatchErrors = []string{"unsupported protocol", "wrong version number", "alert protocol version",
+		"no protocols available", "version too low"}
+)
+
+// offeredCiphers returns the cipher list configured for opts, as passed to
+// SetCipherList.
+func offeredCiphers(opts options.ToolOptions) string {
+	switch {
+	case opts.SSLCipherList != "":
+		return opts.SSLCipherList
+	case opts.SSLStrict:
+		return strictCipherList
+	}
+	return defaultCipherList
+}
+
+// minimumProtocol returns the oldest protocol version the ctx options leave
+// enabled. Versions OpenSSL was built without aren't accounted for.
+func minimumProtocol(ctxOptions openssl.Options) string {
+	disabled := []struct {
+		option  openssl.Options
+		version string
+	}{
+		{openssl.NoSSLv3, "SSLv3"},
+		{openssl.NoTLSv1, "TLSv1"},
+		{openssl.NoTLSv1_1, "TLSv1.1"},
+		{openssl.NoTLSv1_2, "TLSv1.2"},
+	}
+	for _, d := range disabled {
+		if ctxOptions&d.option == 0 {
+			return d.version
+		}
+	}
+	return "TLSv1.3"
+}
+
+// describeHandshakeMismatch adds what the client offered to a handshake error
+// caused by the client and server having no cipher or protocol version in
+// common, and returns any other error unchanged.
+func (self *SSLDBConnector) describeHandshakeMismatch(err error) error {
+	message := strings.ToLower(err.Error())
+	for _, fragment := range cipherMismatchErrors {
+		if strings.Contains(message, fragment) {
+			return fmt.Errorf("%v (the client offered ciphers '%v' with %v or later; "+
+				"check that --sslCipherList selects a cipher the server accepts)",
+				err, offeredCiphers(self.opts), minimumProtocol(self.ctx.GetOptions()))
+		}
+	}
+	for _, fragment := range protocolMismatchErrors {
+		if strings.Contains(message, fragment) {
+			return fmt.Errorf("%v (the client offered %v or later; check the protocol versions the server accepts)",
+				err, minimumProtocol(self.ctx.GetOptions()))
+		}
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDescribeHandshakeMismatch(t *testing.T) {
+	connector := configuredConnector(t, "localhost", nil)
+	defer connector.Close()
+
+	cases := []struct {
+		Error     string
+		Described string
+	}{
+		{Error: "SSL routines:ssl3_get_client_hello:no shared cipher", Described: "the client offered ciphers"},
+		{Error: "SSL routines:ssl_cipher_list_to_bytes:no ciphers available", Described: "the client offered ciphers"},
+		{Error: "SSL routines:ssl3_get_record:wrong version number", Described: "check the protocol versions"},
+		{Error: "SSL routines:ssl3_read_bytes:tlsv1 alert protocol version", Described: "check the protocol versions"},
+		// servers send this for failures other than a mismatch too
+		{Error: "SSL routines:ssl3_read_bytes:sslv3 alert handshake failure"},
+		{Error: "SSL routines:tls_process_server_certificate:certificate verify failed"},
+	}
+
+	for _, v := range cases {
+		err := connector.describeHandshakeMismatch(errors.New(v.Error))
+		if v.Described == "" {
+			if err.Error() != v.Error {
+				t.Errorf("%q should be returned unchanged, got %q", v.Error, err)
+			}
+		} else if !strings.Contains(err.Error(), v.Described) {
+			t.Errorf("%q should be described with %q, got %q", v.Error, v.Described, err)
+		}
+	}
+}
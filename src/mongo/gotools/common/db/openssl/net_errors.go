@@ -0,0 +1,122 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// NetErrorKind classifies why a TCP connection to a server failed.
+type NetErrorKind int
+
+const (
+	// a failure not covered by the other kinds
+	NetErrorOther NetErrorKind = iota
+	// nothing was listening on the port, so the server is probably down
+	NetErrorRefused
+	// no route to the host, usually a firewall or routing problem
+	NetErrorHostUnreachable
+	// no route to the host's network
+	NetErrorNetworkUnreachable
+	// the connection attempt timed out
+	NetErrorTimeout
+)
+
+func (k NetErrorKind) String() string {
+	switch k {
+	case NetErrorRefused:
+		return "connection refused"
+	case NetErrorHostUnreachable:
+		return "host unreachable"
+	case NetErrorNetworkUnreachable:
+		return "network unreachable"
+	case NetErrorTimeout:
+		return "timeout"
+	}
+	return "other"
+}
+
+// NetError is returned when a TCP connection to a server can't be made.
+type NetError struct {
+	Kind    NetErrorKind
+	Address string
+	Err     error
+}
+
+func (e *NetError) Error() string {
+	return e.Err.Error()
+}
+
+// newNetError classifies an error returned by net.Dial for address.
+func newNetError(address string, err error) *NetError {
+	netErr := &NetError{Kind: NetErrorOther, Address: address, Err: err}
+	opErr, ok := err.(*net.OpError)
+	if !ok {
+		return netErr
+	}
+	if opErr.Timeout() {
+		netErr.Kind = NetErrorTimeout
+		return netErr
+	}
+	cause := opErr.Err
+	if syscallErr, ok := cause.(*os.SyscallError); ok {
+		cause = syscallErr.Err
+	}
+	switch cause {
+	case syscall.ECONNREFUSED:
+		netErr.Kind = NetErrorRefused
+	case syscall.EHOSTUNREACH:
+		netErr.Kind = NetErrorHostUnreachable
+	case syscall.ENETUNREACH:
+		netErr.Kind = NetErrorNetworkUnreachable
+	case syscall.ETIMEDOUT:
+		netErr.Kind = NetErrorTimeout
+	}
+	return netErr
+}
+
+// errNoReachableServers is the message of the error mgo returns when it
+// couldn't connect to any server.
+const errNoReachableServers = "no reachable servers"
+
+// netErrorRecorder keeps the last TCP connection failure while establishing a
+// session, so that it can be returned in place of mgo's error, which doesn't
+// say why no server could be reached. A nil recorder keeps nothing.
+type netErrorRecorder struct {
+	mu   sync.Mutex
+	last *NetError
+}
+
+// record keeps err if it's a *NetError.
+func (r *netErrorRecorder) record(err error) {
+	netErr, ok := err.(*NetError)
+	if r == nil || !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = netErr
+}
+
+// replace returns the last recorded *NetError if err is mgo's error for not
+// reaching any server, and err otherwise.
+func (r *netErrorRecorder) replace(err error) error {
+	if r == nil || err == nil || err.Error() != errNoReachableServers {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last == nil {
+		return err
+	}
+	return r.last
+}
@@ -0,0 +1,119 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "i/o timeout" }
+
+func (timeoutError) Timeout() bool { return true }
+
+func (timeoutError) Temporary() bool { return true }
+
+func dialError(err error) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: err}
+}
+
+func TestNewNetError(t *testing.T) {
+	cases := []struct {
+		Name string
+		Err  error
+		Kind NetErrorKind
+	}{
+		{Name: "refused", Err: dialError(os.NewSyscallError("connect", syscall.ECONNREFUSED)), Kind: NetErrorRefused},
+		{Name: "host unreachable", Err: dialError(os.NewSyscallError("connect", syscall.EHOSTUNREACH)), Kind: NetErrorHostUnreachable},
+		{Name: "network unreachable", Err: dialError(os.NewSyscallError("connect", syscall.ENETUNREACH)), Kind: NetErrorNetworkUnreachable},
+		{Name: "connect timed out", Err: dialError(os.NewSyscallError("connect", syscall.ETIMEDOUT)), Kind: NetErrorTimeout},
+		{Name: "dial timeout", Err: dialError(timeoutError{}), Kind: NetErrorTimeout},
+		{Name: "errno without a syscall error", Err: dialError(syscall.ECONNREFUSED), Kind: NetErrorRefused},
+		{Name: "other errno", Err: dialError(os.NewSyscallError("connect", syscall.EACCES)), Kind: NetErrorOther},
+		{Name: "not an OpError", Err: errors.New("something else"), Kind: NetErrorOther},
+	}
+
+	for _, v := range cases {
+		netErr := newNetError("host:27017", v.Err)
+		if netErr.Kind != v.Kind {
+			t.Errorf("%v: kind is %v, expected %v", v.Name, netErr.Kind, v.Kind)
+		}
+		if netErr.Address != "host:27017" || netErr.Err != v.Err {
+			t.Errorf("%v: error doesn't keep the address and cause: %+v", v.Name, netErr)
+		}
+		if netErr.Error() != v.Err.Error() {
+			t.Errorf("%v: message is %q, expected %q", v.Name, netErr.Error(), v.Err.Error())
+		}
+	}
+}
+
+func TestDialNetError(t *testing.T) {
+	address := closedAddr(t)
+	connector := localConnector(t, address, "testdata/ca.pem", nil)
+	defer connector.Close()
+
+	_, err := connector.dial(address)
+	netErr, ok := err.(*NetError)
+	if !ok {
+		t.Fatalf("Expected a *NetError dialing a closed port, got %T: %v", err, err)
+	}
+	if netErr.Kind != NetErrorRefused || netErr.Address != address {
+		t.Errorf("Error is %v for %v, expected %v for %v", netErr.Kind, netErr.Address, NetErrorRefused, address)
+	}
+}
+
+func TestSessionNetError(t *testing.T) {
+	address := closedAddr(t)
+	connector := localConnector(t, address, "testdata/ca.pem", func(opts *options.ToolOptions) {
+		opts.Timeout = 1
+	})
+	defer connector.Close()
+
+	_, err := connector.GetNewSession()
+	netErr, ok := err.(*NetError)
+	if !ok {
+		t.Fatalf("Expected a *NetError when no server is reachable, got %T: %v", err, err)
+	}
+	if netErr.Kind != NetErrorRefused || netErr.Address != address {
+		t.Errorf("Error is %v for %v, expected %v for %v", netErr.Kind, netErr.Address, NetErrorRefused, address)
+	}
+}
+
+func TestNetErrorRecorderReplace(t *testing.T) {
+	noReachable := errors.New(errNoReachableServers)
+	other := errors.New("authentication failed")
+	netErr := newNetError("host:27017", dialError(os.NewSyscallError("connect", syscall.ECONNREFUSED)))
+
+	recorder := &netErrorRecorder{}
+	if err := recorder.replace(noReachable); err != noReachable {
+		t.Errorf("Expected mgo's error to be kept without a recorded failure, got %v", err)
+	}
+	recorder.record(errors.New("handshake failed"))
+	if err := recorder.replace(noReachable); err != noReachable {
+		t.Errorf("Expected only a *NetError to be recorded, got %v", err)
+	}
+	recorder.record(netErr)
+	if err := recorder.replace(noReachable); err != netErr {
+		t.Errorf("Expected the recorded *NetError in place of mgo's error, got %v", err)
+	}
+	if err := recorder.replace(other); err != other {
+		t.Errorf("Expected other errors to be kept, got %v", err)
+	}
+	if err := recorder.replace(nil); err != nil {
+		t.Errorf("Expected no error to stay nil, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+// maxOCSPResponseSize limits how much of a response is read from an OCSP
+// responder.
+const maxOCSPResponseSize = 1 << 20
+
+// defaultOCSPCacheTime is how long a response without a next update time is
+// cached for.
+const defaultOCSPCacheTime = time.Hour
+
+// ocspClockSkew is how far a response's validity period may be off from the
+// local clock, since the responder's clock may differ slightly.
+const ocspClockSkew = 5 * time.Minute
+
+// ocspChecker asks the OCSP responders of the certificates in servers'
+// chains, intermediates as well as the server's own, whether they have been
+// revoked. Only a revoked response fails the check; a certificate whose
+// status can't be determined, because it names no responder, its issuer
+// isn't known or the responder can't be reached, is logged and accepted.
+type ocspChecker struct {
+	// the CA file's certificates, which issue the top of the chains
+	cas     []*x509.Certificate
+	timeout time.Duration
+
+	mu sync.Mutex
+	// responses by issuer and serial number, kept until they're due to be
+	// updated
+	cache map[string]ocspCacheEntry
+}
+
+type ocspCacheEntry struct {
+	response *ocsp.Response
+	expires  time.Time
+}
+
+func newOCSPChecker(caFile string, timeout time.Duration) (*ocspChecker, error) {
+	checker := &ocspChecker{timeout: timeout, cache: map[string]ocspCacheEntry{}}
+	if caFile != "" {
+		pemBytes, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %v", err)
+		}
+		if checker.cas, err = parseChain(pemBytes); err != nil {
+			return nil, fmt.Errorf("error reading CA file: %v", err)
+		}
+	}
+	return checker, nil
+}
+
+// check returns an error if any certificate in the chain presented on conn
+// has been revoked according to its OCSP responder. Self-signed roots are
+// trust anchors, so they aren't checked.
+func (c *ocspChecker) check(conn *openssl.Conn) error {
+	chain, err := conn.PeerCertificateChain()
+	if err != nil {
+		return fmt.Errorf("error getting the server's certificate chain: %v", err)
+	}
+	parsed := make([]*x509.Certificate, 0, len(chain))
+	for _, cert := range chain {
+		x509Cert, err := toX509(cert)
+		if err != nil {
+			return fmt.Errorf("error parsing the server's certificate chain: %v", err)
+		}
+		parsed = append(parsed, x509Cert)
+	}
+
+	for i, cert := range parsed {
+		if i > 0 && isSelfSigned(cert) {
+			continue
+		}
+		if len(cert.OCSPServer) == 0 {
+			log.Logvf(log.DebugLow, "certificate %v names no OCSP responder, not checking its revocation status", cert.Subject)
+			continue
+		}
+		issuer := c.issuerOf(cert, parsed)
+		if issuer == nil {
+			log.Logvf(log.DebugLow, "the issuer of certificate %v is unknown, not checking its revocation status", cert.Subject)
+			continue
+		}
+		response, err := c.status(cert, issuer)
+		if err != nil {
+			log.Logvf(log.Always, "WARNING: couldn't check the revocation status of certificate %v over OCSP: %v", cert.Subject, err)
+			continue
+		}
+		if response.Status == ocsp.Revoked {
+			return fmt.Errorf("certificate %v was revoked at %v according to its OCSP responder",
+				cert.Subject, response.RevokedAt)
+		}
+	}
+	return nil
+}
+
+// issuerOf returns the certificate in chain or the CA file that issued cert,
+// or nil if there's none.
+func (c *ocspChecker) issuerOf(cert *x509.Certificate, chain []*x509.Certificate) *x509.Certificate {
+	for _, candidates := range [][]*x509.Certificate{chain, c.cas} {
+		for _, candidate := range candidates {
+			if candidate != cert && bytes.Equal(cert.RawIssuer, candidate.RawSubject) &&
+				cert.CheckSignatureFrom(candidate) == nil {
+				return candidate
+			}
+		}
+	}
+	return nil
+}
+
+// status returns the response of cert's OCSP responder, from the cache if it
+// isn't due to be updated yet.
+func (c *ocspChecker) status(cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	keyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	key := hex.EncodeToString(keyHash[:]) + "/" + cert.SerialNumber.String()
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.response, nil
+	}
+
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OCSP request: %v", err)
+	}
+	var response *ocsp.Response
+	for _, url := range cert.OCSPServer {
+		if response, err = c.query(url, request, cert, issuer); err == nil {
+			break
+		}
+		err = fmt.Errorf("error querying %v: %v", url, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expires := response.NextUpdate
+	if expires.IsZero() {
+		expires = now.Add(defaultOCSPCacheTime)
+	}
+	c.mu.Lock()
+	c.cache[key] = ocspCacheEntry{response: response, expires: expires}
+	c.mu.Unlock()
+	return response, nil
+}
+
+// query posts request to the OCSP responder at url and returns its response
+// about cert, once its signature has been checked.
+func (c *ocspChecker) query(url string, request []byte, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("unsupported URL scheme")
+	}
+	client := http.Client{Timeout: c.timeout}
+	resp, err := client.Post(url, "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %v", resp.Status)
+	}
+	data, err := ioutil.ReadAll(&io.LimitedReader{R: resp.Body, N: maxOCSPResponseSize})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := ocsp.ParseResponse(data, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing OCSP response: %v", err)
+	}
+	if response.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		return nil, fmt.Errorf("OCSP response is for serial number %v, not %v", response.SerialNumber, cert.SerialNumber)
+	}
+	if err = checkOCSPFreshness(response, time.Now()); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// checkOCSPFreshness returns an error unless response is valid at now, so that
+// an old response, which a responder or anyone in between could replay after
+// the certificate was revoked, isn't trusted.
+func checkOCSPFreshness(response *ocsp.Response, now time.Time) error {
+	if response.ThisUpdate.After(now.Add(ocspClockSkew)) {
+		return fmt.Errorf("OCSP response is not valid until %v", response.ThisUpdate)
+	}
+	if !response.NextUpdate.IsZero() && response.NextUpdate.Before(now.Add(-ocspClockSkew)) {
+		return fmt.Errorf("OCSP response expired at %v", response.NextUpdate)
+	}
+	return nil
+}
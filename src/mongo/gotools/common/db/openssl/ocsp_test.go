@@ -0,0 +1,117 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestCheckOCSPFreshness(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		Name       string
+		ThisUpdate time.Time
+		NextUpdate time.Time
+		Valid      bool
+	}{
+		{Name: "current", ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour), Valid: true},
+		{Name: "no next update", ThisUpdate: now.Add(-time.Hour), Valid: true},
+		{Name: "issued slightly ahead of the local clock", ThisUpdate: now.Add(time.Minute), NextUpdate: now.Add(time.Hour), Valid: true},
+		{Name: "expired within the clock skew", ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(-time.Minute), Valid: true},
+		{Name: "expired", ThisUpdate: now.Add(-2 * time.Hour), NextUpdate: now.Add(-time.Hour), Valid: false},
+		{Name: "not yet valid", ThisUpdate: now.Add(time.Hour), NextUpdate: now.Add(2 * time.Hour), Valid: false},
+	}
+
+	for _, v := range cases {
+		err := checkOCSPFreshness(&ocsp.Response{ThisUpdate: v.ThisUpdate, NextUpdate: v.NextUpdate}, now)
+		if v.Valid && err != nil {
+			t.Errorf("%v: unexpected error: %v", v.Name, err)
+		} else if !v.Valid && err == nil {
+			t.Errorf("%v: expected an error but the response was accepted", v.Name)
+		}
+	}
+}
+
+func TestOCSPStatus(t *testing.T) {
+	ca := newTestCA(t, "OCSP Test CA")
+	var template ocsp.Response
+	var queries int32
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queries, 1)
+		data, err := ocsp.CreateResponse(ca.cert, ca.cert, template, ca.key)
+		if err != nil {
+			t.Errorf("Error creating OCSP response: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(data)
+	}))
+	defer responder.Close()
+
+	now := time.Now()
+	cases := []struct {
+		Name       string
+		Status     int
+		ThisUpdate time.Time
+		NextUpdate time.Time
+		Valid      bool
+		Cached     bool
+	}{
+		{Name: "good", Status: ocsp.Good, ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour), Valid: true, Cached: true},
+		{Name: "revoked", Status: ocsp.Revoked, ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour), Valid: true, Cached: true},
+		{Name: "stale", Status: ocsp.Good, ThisUpdate: now.Add(-2 * time.Hour), NextUpdate: now.Add(-time.Hour), Valid: false},
+		{Name: "not yet valid", Status: ocsp.Good, ThisUpdate: now.Add(time.Hour), NextUpdate: now.Add(2 * time.Hour), Valid: false},
+	}
+
+	for _, v := range cases {
+		leaf := newTestCert(t, v.Name, x509.Certificate{OCSPServer: []string{responder.URL}}, ca)
+		template = ocsp.Response{
+			Status:       v.Status,
+			SerialNumber: leaf.cert.SerialNumber,
+			ThisUpdate:   v.ThisUpdate,
+			NextUpdate:   v.NextUpdate,
+			RevokedAt:    v.ThisUpdate,
+		}
+
+		checker := &ocspChecker{timeout: time.Second, cache: map[string]ocspCacheEntry{}}
+		atomic.StoreInt32(&queries, 0)
+		for i := 0; i < 2; i++ {
+			response, err := checker.status(leaf.cert, ca.cert)
+			if !v.Valid {
+				if err == nil {
+					t.Errorf("%v: expected an error but the response was accepted", v.Name)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%v: error checking status: %v", v.Name, err)
+			} else if response.Status != v.Status {
+				t.Errorf("%v: status is %v, expected %v", v.Name, response.Status, v.Status)
+			}
+		}
+
+		expected := int32(2)
+		if v.Cached {
+			expected = 1
+		}
+		if n := atomic.LoadInt32(&queries); n != expected {
+			t.Errorf("%v: responder was queried %v times, expected %v", v.Name, n, expected)
+		}
+		if cached := len(checker.cache) > 0; cached != v.Cached {
+			t.Errorf("%v: cached is %v, expected %v", v.Name, cached, v.Cached)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+	"github.com/mongodb/mongo-tools/common/options"
+	"github.com/mongodb/mongo-tools/common/util"
+)
+
+// VerifyChainOffline checks a saved server certificate chain, in PEM form with
+// the server's certificate first, the way it would be checked when connecting
+// with opts, without contacting the server. The chain is verified against the
+// CA file and system CAs, checked for expiry and against the CRL file, and
+// its hostname is checked against the first host in opts.
+func VerifyChainOffline(chainPEM []byte, opts options.ToolOptions) error {
+	chain, err := parseChain(chainPEM)
+	if err != nil {
+		return err
+	}
+	leaf := chain[0]
+
+	if opts.SSLAllowInvalidCert {
+		log.Logvf(log.DebugLow, "not verifying chain of certificate %v, since invalid certificates are allowed", leaf.Subject)
+		return nil
+	}
+	if opts.SSLAllowNonCASigner {
+		return fmt.Errorf("allowing non-CA signers is not supported when verifying a chain offline")
+	}
+
+	verifyOpts := x509.VerifyOptions{
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   time.Now(),
+	}
+	if verifyOpts.Roots, err = trustedRootPool(opts); err != nil {
+		return err
+	}
+	var trusted []*x509.Certificate
+	if opts.SSLIgnoreRootInChain {
+		if trusted, err = trustedRoots(opts.SSLCAFile); err != nil {
+			return err
+		}
+	}
+	for _, cert := range chain[1:] {
+		if opts.SSLIgnoreRootInChain && matchesTrustedRoot(cert, trusted) {
+			continue
+		}
+		verifyOpts.Intermediates.AddCert(cert)
+	}
+
+	verified, err := leaf.Verify(verifyOpts)
+	if err != nil {
+		return fmt.Errorf("certificate verification failed: %v", err)
+	}
+
+	if opts.SSLCRLFile != "" {
+		issuer := leaf
+		if len(verified[0]) > 1 {
+			issuer = verified[0][1]
+		}
+		if err = checkRevocation(leaf, issuer, opts.SSLCRLFile); err != nil {
+			return err
+		}
+	}
+
+	if !opts.SSLAllowInvalidHost {
+		host, err := offlineServerName(opts)
+		if err != nil {
+			return err
+		}
+		cert, err := openssl.LoadCertificateFromPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}))
+		if err != nil {
+			return fmt.Errorf("error loading server certificate: %v", err)
+		}
+		if err = verifyCertName(cert, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseChain parses every certificate in chainPEM, in order.
+func parseChain(chainPEM []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, chainPEM = pem.Decode(chainPEM)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate in chain: %v", err)
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificates found in chain")
+	}
+	return chain, nil
+}
+
+// trustedRootPool builds the pool of trusted certificates the same way the
+// connector builds its trust store: the CA file, plus the system CAs if there
+// is no CA file or they're explicitly enabled.
+func trustedRootPool(opts options.ToolOptions) (*x509.CertPool, error) {
+	roots := x509.NewCertPool()
+	if opts.SSLCAFile == "" || opts.SSLUseSystemCA {
+		var err error
+		if roots, err = x509.SystemCertPool(); err != nil {
+			return nil, fmt.Errorf("error loading system CA certificates: %v", err)
+		}
+	}
+	if opts.SSLCAFile != "" {
+		pemBytes, err := ioutil.ReadFile(opts.SSLCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %v", err)
+		}
+		if !roots.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("no certificates found in CA file %v", opts.SSLCAFile)
+		}
+	}
+	return roots, nil
+}
+
+// checkRevocation checks leaf against the CRLs in the files matching
+// crlFile that were issued by issuer. As when connecting, only the server's
+// certificate is checked, and a CRL from its issuer must be present.
+func checkRevocation(leaf, issuer *x509.Certificate, crlFile string) error {
+	files := []string{crlFile}
+	if isGlobPattern(crlFile) {
+		var err error
+		if files, err = filepath.Glob(crlFile); err != nil {
+			return fmt.Errorf("invalid CRL file pattern '%v': %v", crlFile, err)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no CRL files match '%v'", crlFile)
+		}
+	}
+
+	found := false
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error loading CRL file %v: %v", file, err)
+		}
+		crl, err := x509.ParseCRL(data)
+		if err != nil {
+			return fmt.Errorf("error loading CRL file %v: %v", file, err)
+		}
+		if issuer.CheckCRLSignature(crl) != nil {
+			// issued by some other CA
+			continue
+		}
+		found = true
+		if crl.HasExpired(time.Now()) {
+			return fmt.Errorf("CRL file %v has expired", file)
+		}
+		for _, revoked := range crl.TBSCertList.RevokedCertificates {
+			if revoked.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+				return fmt.Errorf("certificate %v has been revoked", leaf.Subject)
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("no CRL found for the issuer of certificate %v", leaf.Subject)
+	}
+	return nil
+}
+
+// offlineServerName returns the host the connector would check the server's
+// certificate against, which is the first host it would connect to.
+func offlineServerName(opts options.ToolOptions) (string, error) {
+	var addrs []string
+	if opts.URI != nil && opts.URI.ConnectionString != "" {
+		addrs = opts.URI.GetConnectionAddrs()
+	} else {
+		addrs = util.CreateConnectionAddrs(opts.Host, opts.Port)
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no host to verify the server certificate against")
+	}
+	host := addrs[0]
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host, nil
+}
@@ -0,0 +1,90 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestVerifyChainOffline(t *testing.T) {
+	dir, cleanup := testDir(t, "offline")
+	defer cleanup()
+
+	root := newTestCA(t, "Offline Root CA")
+	intermediate := newTestCert(t, "Offline Intermediate CA", x509.Certificate{
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, root)
+	server := x509.Certificate{DNSNames: []string{"db.example.com"}}
+	leaf := newTestCert(t, "server", server, intermediate)
+	revoked := newTestCert(t, "revoked", server, intermediate)
+	expiredTemplate := server
+	expiredTemplate.NotBefore = time.Now().Add(-2 * time.Hour)
+	expiredTemplate.NotAfter = time.Now().Add(-time.Hour)
+	expired := newTestCert(t, "expired", expiredTemplate, intermediate)
+	untrusted := newTestCert(t, "untrusted", server, newTestCA(t, "Untrusted CA"))
+
+	caFile := pemFile(t, dir, "ca.pem", root)
+	crl := crlFile(t, dir, "intermediate.crl", intermediate, revoked)
+
+	cases := []struct {
+		Name  string
+		Chain []byte
+		Host  string
+		With  func(opts *options.ToolOptions)
+		Valid bool
+	}{
+		{Name: "valid chain", Chain: chainPEM(leaf, intermediate), Valid: true},
+		{Name: "chain including the root", Chain: chainPEM(leaf, intermediate, root), Valid: true},
+		{Name: "missing intermediate", Chain: chainPEM(leaf)},
+		{Name: "untrusted chain", Chain: chainPEM(untrusted)},
+		{Name: "expired certificate", Chain: chainPEM(expired, intermediate)},
+		{Name: "wrong host", Chain: chainPEM(leaf, intermediate), Host: "other.example.com"},
+		{Name: "wrong host allowed", Chain: chainPEM(leaf, intermediate), Host: "other.example.com", Valid: true,
+			With: func(opts *options.ToolOptions) { opts.SSLAllowInvalidHost = true }},
+		{Name: "invalid certificate allowed", Chain: chainPEM(untrusted), Valid: true,
+			With: func(opts *options.ToolOptions) { opts.SSLAllowInvalidCert = true }},
+		{Name: "non-CA signers", Chain: chainPEM(leaf, intermediate),
+			With: func(opts *options.ToolOptions) { opts.SSLAllowNonCASigner = true }},
+		{Name: "not revoked", Chain: chainPEM(leaf, intermediate), Valid: true,
+			With: func(opts *options.ToolOptions) { opts.SSLCRLFile = crl }},
+		{Name: "revoked", Chain: chainPEM(revoked, intermediate),
+			With: func(opts *options.ToolOptions) { opts.SSLCRLFile = crl }},
+		{Name: "no CRL from the issuer", Chain: chainPEM(leaf, intermediate),
+			With: func(opts *options.ToolOptions) {
+				opts.SSLCRLFile = crlFile(t, dir, "other.crl", newCRLIssuer(t, "Other CA"))
+			}},
+		{Name: "CRL pattern", Chain: chainPEM(revoked, intermediate),
+			With: func(opts *options.ToolOptions) { opts.SSLCRLFile = filepath.Join(dir, "*.crl") }},
+		{Name: "no certificates", Chain: []byte("not a certificate")},
+	}
+
+	for _, v := range cases {
+		host := v.Host
+		if host == "" {
+			host = "db.example.com"
+		}
+		opts := options.ToolOptions{
+			Connection: &options.Connection{Host: host, Port: "27017"},
+			SSL:        &options.SSL{UseSSL: true, SSLCAFile: caFile},
+		}
+		if v.With != nil {
+			v.With(&opts)
+		}
+		if err := VerifyChainOffline(v.Chain, opts); (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+	}
+}
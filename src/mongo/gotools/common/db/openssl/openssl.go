@@ -10,12 +10,18 @@
 package openssl
 
 import (
+	"crypto/x509"
+	"encoding/asn1"
 	"fmt"
 	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/connstring"
 	"github.com/mongodb/mongo-tools/common/db/kerberos"
+	"github.com/mongodb/mongo-tools/common/db/writeconcern"
 	"github.com/mongodb/mongo-tools/common/log"
 	"github.com/mongodb/mongo-tools/common/options"
 	"github.com/mongodb/mongo-tools/common/util"
@@ -24,58 +30,337 @@ import (
 
 // For connecting to the database over ssl
 type SSLDBConnector struct {
+	connectorConfig
+
+	// keeps a session ready for GetNewSession, if enabled
+	standby *warmStandby
+	// keeps the seed list from an SRV record current, if polling is enabled
+	srv *srvPoller
+
+	// spreads sessions across the seed mongos, if load balancing is enabled
+	balancer *mongosBalancer
+
+	// the most recent connection attempts, if enabled
+	attempts *attemptLog
+
+	// counts connection attempts and their outcomes
+	metrics connectionMetrics
+
+	// the addresses of the most recent successful connection
+	lastConn connAddrs
+	// the extensions negotiated on the most recent successful connection
+	lastExtensions connExtensions
+
+	// tracks open connections so Close can wait for them, if a shutdown
+	// grace period was configured
+	tracker *connTracker
+
+	// the shared session, once this connector has acquired it
+	sharedLock sync.Mutex
+	shared     *sharedSession
+}
+
+// connectorConfig is the part of a connector set up by Configure and the
+// setters, which a clone starts out with a copy of.
+type connectorConfig struct {
 	dialInfo *mgo.DialInfo
 	ctx      *openssl.Ctx
+
+	// the options the connector was configured with, for setting up
+	// contexts for one-off connections
+	opts options.ToolOptions
+
+	// write concern applied to new sessions, if one was configured
+	setSafe bool
+	safe    *mgo.Safe
+
+	// records every successful connection, if an audit file was configured
+	audit *auditLog
+
+	// warns about server certificates that are about to expire, if enabled
+	expiry *expiryWarner
+
+	flags     openssl.DialFlags
+	keepAlive time.Duration
+	linger    *time.Duration
+	// socket buffer sizes, or zero for the OS default
+	readBuffer  int
+	writeBuffer int
+
+	// how often sessions the connector holds on to are pinged while idle,
+	// if set
+	appKeepAlive time.Duration
+
+	// connects to every seed and keeps the fastest, if enabled
+	selectFastest bool
+	// the address of the member every session is pinned to, if any
+	pinnedMember string
+
+	// fetches intermediates missing from servers' chains, if enabled
+	intermediates *intermediateFetcher
+	// checks servers' chains for revoked certificates over OCSP, if enabled
+	ocsp *ocspChecker
+
+	// the keys servers presented on first use, if trusting on first use
+	knownHosts *knownHosts
+
+	// certificate policies the server's chain must assert, and the CAs its
+	// verified chain is built to
+	requiredPolicies []asn1.ObjectIdentifier
+	policyRoots      *x509.CertPool
+	// our own certificate, whose issuer must issue the server's too, if
+	// enabled
+	sameIssuer *x509.Certificate
+	// the CAs whose key the server's chain must identify, if enabled
+	akiCAs []*x509.Certificate
+	// the certificates and roots in the CA file that a chain accepted under
+	// --sslAllowNonCASigner must lead up to
+	nonCASignerCAs   []*x509.Certificate
+	nonCASignerRoots []*x509.Certificate
+
+	// applied to errors before they are returned, if set
+	errorFormatter func(phase string, err error) error
+	// receives an event for every connection attempt, if set
+	eventSink func(event ConnectionEvent)
+
+	// how long Close waits for open connections, if set
+	shutdownGrace time.Duration
+
+	// the largest message accepted from the server, if limited
+	maxMessageSize int
+
+	// how long health checks wait for a server, if shorter than the
+	// connection timeout
+	healthTimeout time.Duration
+
+	// the most connection attempts made while establishing a session, if
+	// limited
+	totalDialAttempts int
+
+	// set if sessions are shared with identically configured connectors
+	poolKey string
 }
 
 // Configure the connector to connect to the server over ssl. Parses the
 // connection string, and sets up the correct function to dial the server
 // based on the ssl options passed in.
 func (self *SSLDBConnector) Configure(opts options.ToolOptions) error {
+	return self.phaseError(phaseConfigure, self.configure(opts))
+}
+
+func (self *SSLDBConnector) configure(opts options.ToolOptions) error {
+
+	if err := opts.ValidateDriverOptions(); err != nil {
+		return err
+	}
+
+	// mgo can't carry out the signed SASL conversation MONGODB-AWS uses, so
+	// there's no point sourcing AWS credentials for it
+	if opts.Auth.Mechanism == "MONGODB-AWS" {
+		return fmt.Errorf("MONGODB-AWS authentication is not supported by this connector")
+	}
+
+	if opts.SSLStrict {
+		if err := validateStrictOptions(opts); err != nil {
+			return err
+		}
+	}
+	if err := validateInsecureAcknowledged(opts); err != nil {
+		return err
+	}
+	if opts.SSLVerifyAuditOnly {
+		if err := validateAuditOnlyOptions(opts); err != nil {
+			return err
+		}
+	}
+
+	// shared connections belong to every connector using them, so one of
+	// them closing mustn't force them closed
+	if opts.ShutdownGrace > 0 && opts.ShareSessions {
+		return fmt.Errorf("a shutdown grace period can't be used with shared sessions")
+	}
+
+	if opts.MongosLoadBalance {
+		if opts.ReplicaSetName != "" {
+			return fmt.Errorf("mongos load balancing can't be used with a replica set name")
+		}
+		if opts.ShareSessions {
+			return fmt.Errorf("mongos load balancing can't be used with shared sessions")
+		}
+		self.balancer = &mongosBalancer{}
+	}
+
+	if opts.SelectFastestNode {
+		if opts.MongosLoadBalance {
+			return fmt.Errorf("selecting the fastest node can't be used with mongos load balancing")
+		}
+		if opts.ShareSessions {
+			return fmt.Errorf("selecting the fastest node can't be used with shared sessions")
+		}
+		self.selectFastest = true
+	}
+
+	if opts.PinToMember != "" {
+		if opts.MongosLoadBalance || opts.SelectFastestNode {
+			return fmt.Errorf("sessions can't be pinned to a member with mongos load balancing or fastest node selection")
+		}
+		if opts.ShareSessions {
+			return fmt.Errorf("sessions can't be pinned to a member with shared sessions")
+		}
+		self.pinnedMember = normalizeMember(opts.PinToMember)
+	}
 
 	var err error
+	if opts.WriteConcern != "" {
+		self.safe, err = writeconcern.Parse(opts.WriteConcern)
+		if err != nil {
+			return fmt.Errorf("write concern: %v", err)
+		}
+		self.setSafe = true
+	}
+
 	self.ctx, err = setupCtx(opts)
 	if err != nil {
 		return fmt.Errorf("openssl configuration: %v", err)
 	}
+	self.opts = opts
+
+	if opts.SSLAuditFile != "" {
+		self.audit = &auditLog{path: opts.SSLAuditFile}
+	}
+
+	if opts.ConnectionAttemptLogSize < 0 {
+		return fmt.Errorf("connection attempt log size must not be negative, got %v", opts.ConnectionAttemptLogSize)
+	}
+	if opts.ConnectionAttemptLogSize > 0 {
+		self.attempts = newAttemptLog(opts.ConnectionAttemptLogSize, opts.Auth.Password, opts.SSLPEMKeyPassword)
+	}
+
+	if opts.SSLCertExpiryWarning > 0 {
+		self.expiry = newExpiryWarner(time.Duration(opts.SSLCertExpiryWarning) * 24 * time.Hour)
+	}
 
-	var flags openssl.DialFlags
-	flags = 0
+	self.flags = 0
 	if opts.SSLAllowInvalidCert || opts.SSLAllowInvalidHost {
-		flags = openssl.InsecureSkipHostVerification
+		self.flags = openssl.InsecureSkipHostVerification
 	}
-	// create the dialer func that will be used to connect
-	dialer := func(addr *mgo.ServerAddr) (net.Conn, error) {
-		conn, err := openssl.Dial("tcp", addr.String(), self.ctx, flags)
-		if err != nil {
-			// mgo discards dialer errors so log it now
-			log.Logvf(log.Always, "error dialing %v: %v", addr.String(), err)
-			return nil, err
+	if opts.SSLTrustOnFirstUse {
+		if opts.SSLKnownHostsFile == "" {
+			return fmt.Errorf("--sslTrustOnFirstUse requires --sslKnownHostsFile")
 		}
-		// enable TCP keepalive
-		err = util.EnableTCPKeepAlive(conn.UnderlyingConn(), time.Duration(opts.TCPKeepAliveSeconds)*time.Second)
-		if err != nil {
-			// mgo discards dialer errors so log it now
-			log.Logvf(log.Always, "error enabling TCP keepalive on connection to %v: %v", addr.String(), err)
-			conn.Close()
-			return nil, err
+		if opts.SSLCAFile != "" {
+			return fmt.Errorf("--sslTrustOnFirstUse can't be used with --sslCAFile")
+		}
+		if self.knownHosts, err = loadKnownHosts(opts.SSLKnownHostsFile); err != nil {
+			return err
 		}
-		return conn, nil
+		// the recorded key identifies the server in place of its name
+		self.flags = openssl.InsecureSkipHostVerification
+	}
+	if opts.SSLRequireSameIssuer {
+		if opts.SSLPEMKeyFile == "" {
+			return fmt.Errorf("--sslRequireSameIssuer requires --sslPEMKeyFile")
+		}
+		if self.sameIssuer, err = clientCertificate(opts.SSLPEMKeyFile); err != nil {
+			return err
+		}
+	}
+	if opts.SSLAllowNonCASigner && !opts.SSLAllowInvalidCert {
+		if opts.SSLCAFile == "" {
+			return fmt.Errorf("--sslAllowNonCASigner requires --sslCAFile")
+		}
+		if self.nonCASignerRoots, err = trustedRoots(opts.SSLCAFile); err != nil {
+			return err
+		}
+		if self.nonCASignerCAs, err = caFileCertificates(opts.SSLCAFile); err != nil {
+			return err
+		}
+	}
+	if opts.SSLVerifyAKI {
+		if opts.SSLCAFile == "" {
+			return fmt.Errorf("--sslVerifyAKI requires --sslCAFile")
+		}
+		if opts.SSLAllowInvalidCert {
+			return fmt.Errorf("--sslVerifyAKI can't be used with --sslAllowInvalidCertificates")
+		}
+		if self.akiCAs, err = keyIdentifiedCAs(opts.SSLCAFile); err != nil {
+			return err
+		}
+	}
+	if len(opts.SSLRequiredPolicyOIDs) > 0 {
+		if opts.SSLAllowNonCASigner {
+			return fmt.Errorf("--sslRequiredPolicyOID can't be used with --sslAllowNonCASigner")
+		}
+		if self.requiredPolicies, err = parsePolicyOIDs(opts.SSLRequiredPolicyOIDs); err != nil {
+			return err
+		}
+		if self.policyRoots, err = trustedRootPool(opts); err != nil {
+			return err
+		}
+	}
+	self.keepAlive = time.Duration(opts.TCPKeepAliveSeconds) * time.Second
+	if opts.SocketLinger != nil {
+		if err = validateSocketLinger(*opts.SocketLinger); err != nil {
+			return err
+		}
+		linger := *opts.SocketLinger
+		self.linger = &linger
+	}
+	if opts.SocketReadBuffer < 0 {
+		return fmt.Errorf("socket read buffer size must be positive, got %v", opts.SocketReadBuffer)
+	}
+	if opts.SocketWriteBuffer < 0 {
+		return fmt.Errorf("socket write buffer size must be positive, got %v", opts.SocketWriteBuffer)
+	}
+	self.readBuffer, self.writeBuffer = opts.SocketReadBuffer, opts.SocketWriteBuffer
+
+	if opts.MaxMessageSizeBytes < 0 {
+		return fmt.Errorf("maximum message size must not be negative, got %v", opts.MaxMessageSizeBytes)
+	}
+	self.maxMessageSize = opts.MaxMessageSizeBytes
+
+	if opts.TotalDialAttempts < 0 {
+		return fmt.Errorf("total dial attempts must not be negative, got %v", opts.TotalDialAttempts)
+	}
+	if opts.TotalDialAttempts > 0 && opts.ShareSessions {
+		return fmt.Errorf("total dial attempts can't be limited with shared sessions")
+	}
+	self.totalDialAttempts = opts.TotalDialAttempts
+
+	if opts.ShutdownGrace > 0 {
+		self.tracker = newConnTracker()
+		self.shutdownGrace = opts.ShutdownGrace
 	}
 
 	timeout := time.Duration(opts.Timeout) * time.Second
+	if opts.HealthCheckSelectionTimeout < 0 {
+		return fmt.Errorf("health check selection timeout must not be negative, got %v", opts.HealthCheckSelectionTimeout)
+	}
+	self.healthTimeout = opts.HealthCheckSelectionTimeout
+
+	if opts.SSLFetchMissingIntermediates {
+		self.intermediates = newIntermediateFetcher(self.ctx, timeout)
+	}
+	if opts.SSLVerifyOCSPChain {
+		if opts.SSLAllowInvalidCert {
+			return fmt.Errorf("--sslVerifyOCSPChain can't be used with --sslAllowInvalidCertificates")
+		}
+		if self.ocsp, err = newOCSPChecker(opts.SSLCAFile, timeout); err != nil {
+			return err
+		}
+	}
 
 	// set up the dial info
 	self.dialInfo = &mgo.DialInfo{
 		Timeout:        timeout,
 		Direct:         opts.Direct,
 		ReplicaSetName: opts.ReplicaSetName,
-		DialServer:     dialer,
 		Username:       opts.Auth.Username,
 		Password:       opts.Auth.Password,
 		Source:         opts.GetAuthenticationDatabase(),
 		Mechanism:      opts.Auth.Mechanism,
 	}
+	self.bindDialer()
 
 	// create or fetch the addresses to be used to connect
 	if opts.URI != nil && opts.URI.ConnectionString != "" {
@@ -83,14 +368,415 @@ func (self *SSLDBConnector) Configure(opts options.ToolOptions) error {
 	} else {
 		self.dialInfo.Addrs = util.CreateConnectionAddrs(opts.Host, opts.Port)
 	}
-	kerberos.AddKerberosOpts(opts, self.dialInfo)
+	if opts.SSLSkipUnresolvableHosts {
+		if self.dialInfo.Addrs, err = resolvableAddrs(self.dialInfo.Addrs); err != nil {
+			return err
+		}
+	}
+	if err = opts.Auth.ValidateMechanismProperties(); err != nil {
+		return err
+	}
+	if err = kerberos.AddKerberosOpts(opts, self.dialInfo); err != nil {
+		return err
+	}
+	if opts.SSLMatchServerCiphers {
+		// members of a deployment are expected to share their ssl settings,
+		// so the first seed stands in for all of them
+		if err = self.matchServerCiphers(self.dialInfo.Addrs[0], offeredCiphers(opts)); err != nil {
+			return err
+		}
+	}
+
+	if opts.SRVPollInterval != 0 {
+		var cs *connstring.ConnString
+		if opts.URI != nil {
+			cs = opts.URI.ParsedConnString()
+		}
+		if cs == nil || !cs.UsingSRV {
+			return fmt.Errorf("SRV polling requires a mongodb+srv connection string")
+		}
+		if opts.SRVPollInterval < minSRVPollInterval {
+			return fmt.Errorf("SRV poll interval must be at least %v, got %v", minSRVPollInterval, opts.SRVPollInterval)
+		}
+		if opts.ShareSessions {
+			return fmt.Errorf("SRV polling can't be used with shared sessions")
+		}
+		self.srv = newSRVPoller(cs.SRVHost, self.dialInfo.Addrs, opts.SRVPollInterval)
+	}
+	if opts.AppKeepAliveInterval < 0 {
+		return fmt.Errorf("application keep-alive interval must not be negative, got %v", opts.AppKeepAliveInterval)
+	}
+	if opts.AppKeepAliveInterval > 0 && !opts.ShareSessions && !opts.WarmStandby {
+		return fmt.Errorf("an application keep-alive interval requires shared sessions or a warm standby session")
+	}
+	self.appKeepAlive = opts.AppKeepAliveInterval
+	if opts.ShareSessions {
+		self.poolKey = self.sessionPoolKey()
+	}
+	if opts.WarmStandby {
+		self.standby = newWarmStandby(func() (*mgo.Session, error) {
+			return self.newSession(nil)
+		}, self.standbyInterval())
+	}
 	return nil
 
 }
 
-// Dial the server.
+// dial connects to the server at address and completes the ssl handshake.
+func (self *SSLDBConnector) dial(address string) (*openssl.Conn, error) {
+	return self.dialWithTimings(address, nil, nil)
+}
+
+// dialWithTimings is dial, additionally reporting how long each phase of
+// connecting took to timings and a failed TCP connection to netErrors, if
+// they are non-nil.
+func (self *SSLDBConnector) dialWithTimings(address string, timings *timingRecorder, netErrors *netErrorRecorder) (*openssl.Conn, error) {
+	self.metrics.attempt()
+	conn, phases, err := self.connect(address, 0)
+	if err != nil {
+		netErrors.record(err)
+		// mgo discards dialer errors so log it now
+		log.Logvf(log.Always, "error dialing %v: %v", address, err)
+		self.metrics.failure(phases.failed)
+		self.reportAttempt(address, nil, phases.failed, err)
+		return nil, self.phaseError(phases.failed, err)
+	}
+	// enable TCP keepalive
+	err = util.EnableTCPKeepAlive(tcpConn(conn.UnderlyingConn()), self.keepAlive)
+	if err != nil {
+		// mgo discards dialer errors so log it now
+		log.Logvf(log.Always, "error enabling TCP keepalive on connection to %v: %v", address, err)
+		self.metrics.failure(failureKeepAlive)
+		self.reportAttempt(address, conn, failureKeepAlive, err)
+		conn.Close()
+		return nil, self.phaseError(failureKeepAlive, err)
+	}
+	if self.linger != nil {
+		if err = util.SetTCPLinger(tcpConn(conn.UnderlyingConn()), *self.linger); err != nil {
+			// mgo discards dialer errors so log it now
+			log.Logvf(log.Always, "error setting SO_LINGER on connection to %v: %v", address, err)
+			self.metrics.failure(failureLinger)
+			self.reportAttempt(address, conn, failureLinger, err)
+			conn.Close()
+			return nil, self.phaseError(failureLinger, err)
+		}
+	}
+	if self.readBuffer > 0 || self.writeBuffer > 0 {
+		if err = util.SetTCPBufferSizes(tcpConn(conn.UnderlyingConn()), self.readBuffer, self.writeBuffer); err != nil {
+			// mgo discards dialer errors so log it now
+			log.Logvf(log.Always, "error setting socket buffer sizes on connection to %v: %v", address, err)
+			self.metrics.failure(failureBuffers)
+			self.reportAttempt(address, conn, failureBuffers, err)
+			conn.Close()
+			return nil, self.phaseError(failureBuffers, err)
+		}
+	}
+	if self.expiry != nil {
+		self.expiry.check(address, conn)
+	}
+	if self.audit != nil {
+		if err = self.audit.write(newAuditRecord(address, conn)); err != nil {
+			// mgo discards dialer errors so log it now
+			log.Logvf(log.Always, "error writing SSL audit record for %v: %v", address, err)
+			self.metrics.failure(failureAudit)
+			self.reportAttempt(address, conn, failureAudit, err)
+			conn.Close()
+			return nil, self.phaseError(failureAudit, err)
+		}
+	}
+	self.metrics.success(phases.handshake)
+	self.reportAttempt(address, conn, "", nil)
+	self.lastConn.set(conn.LocalAddr(), conn.RemoteAddr())
+	if recorder, ok := conn.UnderlyingConn().(*helloRecorder); ok {
+		self.lastExtensions.set(recorder.extensions())
+	}
+	if timings != nil {
+		timings.recordDial(phases)
+	}
+	return conn, nil
+}
+
+// connect resolves address, opens a TCP connection to it and completes the
+// ssl handshake, verifying the server's hostname unless that was disabled.
+// It returns how long each of those phases took, or on failure which phase
+// failed. A non-zero timeout bounds connecting and the handshake each.
+func (self *SSLDBConnector) connect(address string, timeout time.Duration) (*openssl.Conn, dialPhases, error) {
+	phases := dialPhases{failed: failureAddress}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, phases, err
+	}
+
+	phases.failed = failureDNS
+	start := time.Now()
+	ips, err := net.LookupHost(host)
+	if err != nil {
+		return nil, phases, err
+	}
+	phases.dns = time.Since(start)
+
+	phases.failed = failureTCP
+	start = time.Now()
+	var rawConn net.Conn
+	for _, ip := range ips {
+		rawConn, err = net.DialTimeout("tcp", net.JoinHostPort(ip, port), timeout)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, phases, newNetError(address, err)
+	}
+	phases.tcp = time.Since(start)
+	if timeout > 0 {
+		// don't let a server that accepts connections but never answers the
+		// handshake hold up the caller
+		rawConn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	phases.failed = failureHandshake
+	start = time.Now()
+	recorder := newHelloRecorder(rawConn)
+	conn, err := openssl.Client(recorder, self.ctx)
+	if err != nil {
+		rawConn.Close()
+		return nil, phases, err
+	}
+	if err = conn.SetTlsExtHostName(host); err != nil {
+		conn.Close()
+		return nil, phases, err
+	}
+	if err = conn.Handshake(); err != nil {
+		if self.intermediates != nil && self.intermediates.fetchMissing(conn) {
+			// verify again now that the store has the fetched intermediates
+			conn.Close()
+			return self.connect(address, timeout)
+		}
+		conn.Close()
+		return nil, phases, self.describeHandshakeMismatch(err)
+	}
+	phases.handshakeOK = true
+	if timeout > 0 {
+		rawConn.SetDeadline(time.Time{})
+	}
+	if self.opts.SSLRequireEMS {
+		if err = checkExtendedMasterSecret(recorder.extensions()); err != nil {
+			conn.Close()
+			return nil, phases, err
+		}
+	}
+	if len(self.requiredPolicies) > 0 && !self.opts.SSLAllowInvalidCert {
+		err = self.auditFailure(address, checkCertificatePolicies(conn, self.policyRoots, self.requiredPolicies))
+		if err != nil {
+			conn.Close()
+			return nil, phases, err
+		}
+	}
+	if self.sameIssuer != nil {
+		if err = self.auditFailure(address, checkSameIssuer(conn, self.sameIssuer)); err != nil {
+			conn.Close()
+			return nil, phases, err
+		}
+	}
+	if len(self.nonCASignerRoots) > 0 {
+		err = self.auditFailure(address, checkNonCASignerRoot(conn, self.nonCASignerCAs, self.nonCASignerRoots))
+		if err != nil {
+			conn.Close()
+			return nil, phases, err
+		}
+	}
+	if len(self.akiCAs) > 0 {
+		if err = self.auditFailure(address, checkAuthorityKeyIDs(conn, self.akiCAs)); err != nil {
+			conn.Close()
+			return nil, phases, err
+		}
+	}
+	if self.ocsp != nil {
+		if err = self.auditFailure(address, self.ocsp.check(conn)); err != nil {
+			conn.Close()
+			return nil, phases, err
+		}
+	}
+	if self.flags&openssl.InsecureSkipHostVerification == 0 {
+		phases.failed = failureHostname
+		if err = self.auditFailure(address, verifyServerName(conn, host)); err != nil {
+			conn.Close()
+			return nil, phases, err
+		}
+	}
+	if self.knownHosts != nil {
+		phases.failed = failureKnownHost
+		if err = self.knownHosts.check(address, conn); err != nil {
+			conn.Close()
+			return nil, phases, err
+		}
+	}
+	if self.opts.SSLVerifyAuditOnly {
+		warnAuditOnly(address)
+	}
+	phases.handshake = time.Since(start)
+	phases.done = time.Now()
+	phases.failed = ""
+	return conn, phases, nil
+}
+
+// Dial the server. If no server could be reached, the error is the *NetError
+// from the last failed TCP connection, rather than mgo's "no reachable
+// servers".
 func (self *SSLDBConnector) GetNewSession() (*mgo.Session, error) {
-	return mgo.DialWithInfo(self.dialInfo)
+	if self.standby != nil {
+		if session := self.standby.take(); session != nil {
+			return session, nil
+		}
+	}
+	return self.newSession(nil)
+}
+
+// GetNewSessionWithTimings is GetNewSession, additionally filling in timings
+// with how long each phase of establishing the session took. Timings are
+// filled in even if the session can't be established, for the phases that
+// completed.
+func (self *SSLDBConnector) GetNewSessionWithTimings(timings *ConnectTimings) (*mgo.Session, error) {
+	return self.newSession(timings)
+}
+
+func (self *SSLDBConnector) newSession(timings *ConnectTimings) (*mgo.Session, error) {
+	var session *mgo.Session
+	var err error
+
+	start := time.Now()
+	if self.poolKey != "" {
+		session, err = self.getSharedSession()
+	} else {
+		dialInfo := self.currentDialInfo()
+		var recorder *timingRecorder
+		if timings != nil {
+			recorder = &timingRecorder{timings: timings}
+		}
+		var budget *dialBudget
+		if self.totalDialAttempts > 0 {
+			budget = newDialBudget(self.totalDialAttempts)
+		}
+		netErrors := &netErrorRecorder{}
+		// use a dialer bound to this call so that concurrent callers don't
+		// record or count each other's connections
+		boundInfo := *dialInfo
+		boundInfo.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
+			if !budget.take() {
+				return nil, errDialBudgetExhausted
+			}
+			conn, err := self.dialWithTimings(addr.String(), recorder, netErrors)
+			budget.record(addr.String(), err)
+			if err != nil {
+				return nil, err
+			}
+			return self.serverConn(conn), nil
+		}
+		dialInfo = &boundInfo
+		if self.balancer != nil {
+			session, err = self.balancer.dial(dialInfo)
+		} else if self.selectFastest {
+			session, err = dialFastest(dialInfo)
+		} else if self.pinnedMember != "" {
+			session, err = dialPinned(dialInfo, self.pinnedMember)
+		} else {
+			session, err = mgo.DialWithInfo(dialInfo)
+		}
+		if recorder != nil {
+			recorder.finish(time.Now())
+		}
+		if budget != nil {
+			err = budget.finish(err)
+		}
+		err = netErrors.replace(err)
+	}
+	if timings != nil {
+		timings.Total = time.Since(start)
+	}
+	if err != nil {
+		if authErr := newAuthError(err, self.dialInfo.Mechanism, self.dialInfo.Source); authErr != nil {
+			err = authErr
+		}
+		self.attempts.add(strings.Join(self.currentDialInfo().Addrs, ","), phaseSession, err)
+		return nil, self.phaseError(phaseSession, err)
+	}
+	if self.maxMessageSize > 0 {
+		if err = checkMaxMessageSize(session, self.maxMessageSize); err != nil {
+			session.Close()
+			self.attempts.add(strings.Join(self.currentDialInfo().Addrs, ","), phaseSession, err)
+			return nil, self.phaseError(phaseSession, err)
+		}
+	}
+	if self.setSafe {
+		session.SetSafe(self.safe)
+	}
+	return session, nil
+}
+
+// currentDialInfo returns the dial info for a new session, with the current
+// seed list if it's kept up to date from an SRV record.
+func (self *SSLDBConnector) currentDialInfo() *mgo.DialInfo {
+	if self.srv == nil {
+		return self.dialInfo
+	}
+	dialInfo := *self.dialInfo
+	dialInfo.Addrs = self.srv.current()
+	return &dialInfo
+}
+
+// getSharedSession returns a copy of the session shared with identically
+// configured connectors, acquiring a reference to it on first use.
+func (self *SSLDBConnector) getSharedSession() (*mgo.Session, error) {
+	self.sharedLock.Lock()
+	defer self.sharedLock.Unlock()
+	if self.shared == nil {
+		shared, err := acquireSharedSession(self.poolKey, self.appKeepAlive, func() (*mgo.Session, error) {
+			return mgo.DialWithInfo(self.poolConnector().dialInfo)
+		})
+		if err != nil {
+			return nil, err
+		}
+		self.shared = shared
+	}
+	return self.shared.session.Copy(), nil
+}
+
+// Close releases the resources held by the connector. Sessions previously
+// returned by GetNewSession must still be closed by their callers.
+func (self *SSLDBConnector) Close() {
+	if self.standby != nil {
+		self.standby.close()
+		self.standby = nil
+	}
+	if self.srv != nil {
+		self.srv.close()
+		self.srv = nil
+	}
+
+	self.sharedLock.Lock()
+	if self.shared != nil {
+		releaseSharedSession(self.shared)
+		self.shared = nil
+	}
+	self.sharedLock.Unlock()
+
+	if self.tracker != nil {
+		self.tracker.drain(self.shutdownGrace)
+	}
+}
+
+// serverConn returns conn wrapped for use by mgo: tracked so that Close can
+// wait for it to be closed if a shutdown grace period was configured, and
+// limited to the maximum message size if one was configured.
+func (self *SSLDBConnector) serverConn(conn *openssl.Conn) net.Conn {
+	var wrapped net.Conn = conn
+	if self.tracker != nil {
+		wrapped = self.tracker.track(conn)
+	}
+	if self.maxMessageSize > 0 {
+		wrapped = &messageSizeLimitConn{Conn: wrapped, limit: self.maxMessageSize}
+	}
+	return wrapped
 }
 
 // To be handed to mgo.DialInfo for connecting to the server.
@@ -122,11 +808,55 @@ func setupCtx(opts options.ToolOptions) (*openssl.Ctx, error) {
 	// NoSSLv2 - Disable SSL v2 support
 	ctx.SetOptions(openssl.OpAll | openssl.NoSSLv2)
 
-	// HIGH - Enable strong ciphers
-	// !EXPORT - Disable export ciphers (40/56 bit)
-	// !aNULL - Disable anonymous auth ciphers
-	// @STRENGTH - Sort ciphers based on strength
-	ctx.SetCipherList("HIGH:!EXPORT:!aNULL@STRENGTH")
+	cipherList := defaultCipherList
+	if opts.SSLStrict {
+		if cipherList, err = applyStrictCtx(ctx, opts.SSLCipherList); err != nil {
+			return nil, err
+		}
+	} else if opts.SSLCipherList != "" {
+		cipherList = opts.SSLCipherList
+	}
+	// SetCipherList fails if the list doesn't select any cipher at all
+	if err = ctx.SetCipherList(cipherList); err != nil {
+		return nil, fmt.Errorf("SetCipherList(%v): %v", cipherList, err)
+	}
+
+	if opts.SSLTLS13Ciphers != "" {
+		if err = validateTLS13Ciphers(opts.SSLTLS13Ciphers); err != nil {
+			return nil, err
+		}
+		// the openssl bindings don't wrap SSL_CTX_set_ciphersuites, so the
+		// TLS 1.3 suites can't be constrained separately
+		return nil, fmt.Errorf("setting TLS 1.3 ciphersuites is not supported by this build")
+	}
+
+	if opts.SSLSignatureAlgorithms != "" {
+		if err = validateSignatureAlgorithms(opts.SSLSignatureAlgorithms); err != nil {
+			return nil, err
+		}
+		// the openssl bindings don't wrap SSL_CTX_set1_sigalgs_list
+		return nil, fmt.Errorf("setting signature algorithms is not supported by this build")
+	}
+
+	if opts.SSLMinKeyExchangeStrength != 0 {
+		if opts.SSLMinKeyExchangeStrength < 0 || opts.SSLMinKeyExchangeStrength > 256 {
+			return nil, fmt.Errorf("minimum key exchange strength must be between 1 and 256 bits, got %v",
+				opts.SSLMinKeyExchangeStrength)
+		}
+		// the openssl bindings don't wrap SSL_get_negotiated_group
+		return nil, fmt.Errorf("checking the key exchange group is not supported by this build")
+	}
+
+	if opts.SSLPostHandshakeAuth {
+		// the openssl bindings don't wrap SSL_CTX_set_post_handshake_auth
+		return nil, fmt.Errorf("post-handshake authentication is not supported by this build")
+	}
+
+	if opts.SSLDHParamsFile != "" {
+		// the server picks the DHE group, so parameters set on a client ctx
+		// are never used
+		return nil, fmt.Errorf("DH parameters only apply to servers and can't be set by a client")
+	}
 
 	// add the PEM key file with the cert and private key, if specified
 	if opts.SSLPEMKeyFile != "" {
@@ -152,6 +882,11 @@ func setupCtx(opts options.ToolOptions) (*openssl.Ctx, error) {
 	// If renegotiation is needed, don't return from recv() or send() until it's successful.
 	// Note: this is for blocking sockets only.
 	ctx.SetMode(openssl.AutoRetry)
+	if opts.SSLRejectServerRenegotiation {
+		// the bindings complete renegotiations inside SSL_read and SSL_write
+		// and expose no callback to observe them
+		return nil, fmt.Errorf("rejecting server-initiated renegotiation is not supported by this build")
+	}
 
 	// Disable session caching (see SERVER-10261)
 	ctx.SetSessionCacheMode(openssl.SessionCacheOff)
@@ -165,7 +900,10 @@ func setupCtx(opts options.ToolOptions) (*openssl.Ctx, error) {
 		if err = ctx.LoadVerifyLocations(opts.SSLCAFile, ""); err != nil {
 			return nil, fmt.Errorf("LoadVerifyLocations: %v", err)
 		}
-	} else {
+	}
+	// the system roots are added to the same store, so that intermediates in
+	// the CA file can complete chains up to them
+	if opts.SSLCAFile == "" || opts.SSLUseSystemCA {
 		err = ctx.SetupSystemCA()
 		if err != nil {
 			return nil, fmt.Errorf("Error setting up system certificate authority: %v", err)
@@ -173,12 +911,20 @@ func setupCtx(opts options.ToolOptions) (*openssl.Ctx, error) {
 	}
 
 	var verifyOption openssl.VerifyOptions
-	if opts.SSLAllowInvalidCert {
+	if opts.SSLAllowInvalidCert || opts.SSLTrustOnFirstUse {
+		// with trust on first use the server is verified by its recorded key
 		verifyOption = openssl.VerifyNone
 	} else {
 		verifyOption = openssl.VerifyPeer
 	}
-	ctx.SetVerify(verifyOption, nil)
+	callback, err := verifyCallback(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.SSLVerifyAuditOnly {
+		callback = auditVerifyCallback(callback)
+	}
+	ctx.SetVerify(verifyOption, callback)
 
 	if opts.SSLCRLFile != "" {
 		store := ctx.GetCertificateStore()
@@ -187,8 +933,54 @@ func setupCtx(opts options.ToolOptions) (*openssl.Ctx, error) {
 		if err != nil {
 			return nil, fmt.Errorf("AddLookup(X509LookupFile()): %v", err)
 		}
-		lookup.LoadCRLFile(opts.SSLCRLFile)
+		if isGlobPattern(opts.SSLCRLFile) {
+			if err = loadCRLFiles(lookup, opts.SSLCRLFile); err != nil {
+				return nil, err
+			}
+		} else {
+			lookup.LoadCRLFile(opts.SSLCRLFile)
+		}
 	}
 
 	return ctx, nil
 }
+
+// The TLS 1.3 ciphersuites defined by RFC 8446.
+var tls13Ciphersuites = map[string]bool{
+	"TLS_AES_128_GCM_SHA256":       true,
+	"TLS_AES_256_GCM_SHA384":       true,
+	"TLS_CHACHA20_POLY1305_SHA256": true,
+	"TLS_AES_128_CCM_SHA256":       true,
+	"TLS_AES_128_CCM_8_SHA256":     true,
+}
+
+// validateTLS13Ciphers checks that a colon-separated ciphersuite list is
+// non-empty and only names TLS 1.3 ciphersuites.
+func validateTLS13Ciphers(list string) error {
+	var count int
+	for _, name := range strings.Split(list, ":") {
+		if name == "" {
+			continue
+		}
+		if !tls13Ciphersuites[name] {
+			return fmt.Errorf("unknown TLS 1.3 ciphersuite '%v'", name)
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("TLS 1.3 ciphersuite list '%v' is empty", list)
+	}
+	return nil
+}
+
+// validateSocketLinger checks that linger can be set as SO_LINGER, which only
+// takes whole seconds.
+func validateSocketLinger(linger time.Duration) error {
+	if linger < 0 {
+		return fmt.Errorf("socket linger must not be negative, got %v", linger)
+	}
+	if linger%time.Second != 0 {
+		return fmt.Errorf("socket linger must be a whole number of seconds, got %v", linger)
+	}
+	return nil
+}
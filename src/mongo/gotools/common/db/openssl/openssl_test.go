@@ -0,0 +1,75 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateSocketLinger(t *testing.T) {
+	cases := []struct {
+		Linger time.Duration
+		Valid  bool
+	}{
+		{Linger: 0, Valid: true},
+		{Linger: time.Second, Valid: true},
+		{Linger: 30 * time.Second, Valid: true},
+		{Linger: -time.Second, Valid: false},
+		{Linger: 500 * time.Millisecond, Valid: false},
+		{Linger: 1500 * time.Millisecond, Valid: false},
+	}
+
+	for _, v := range cases {
+		err := validateSocketLinger(v.Linger)
+		if v.Valid && err != nil {
+			t.Errorf("Error validating linger %v: %v", v.Linger, err)
+		} else if !v.Valid && err == nil {
+			t.Errorf("Expected an error validating linger %v but it was accepted", v.Linger)
+		}
+	}
+}
+
+func TestValidateTLS13Ciphers(t *testing.T) {
+	cases := []struct {
+		List  string
+		Valid bool
+	}{
+		{List: "TLS_AES_256_GCM_SHA384", Valid: true},
+		{List: "TLS_AES_128_GCM_SHA256:TLS_CHACHA20_POLY1305_SHA256", Valid: true},
+		{List: "TLS_AES_128_CCM_8_SHA256:", Valid: true},
+		{List: ""},
+		{List: "::"},
+		{List: "ECDHE-RSA-AES128-GCM-SHA256"},
+		{List: "TLS_AES_256_GCM_SHA384:TLS_AES_512_GCM_SHA512"},
+		{List: "tls_aes_256_gcm_sha384"},
+	}
+
+	for _, v := range cases {
+		if err := validateTLS13Ciphers(v.List); (err == nil) != v.Valid {
+			t.Errorf("%q: valid is %v, expected %v: %v", v.List, err == nil, v.Valid, err)
+		}
+	}
+}
+
+func TestConfigureAWSAuth(t *testing.T) {
+	opts := testOptions("localhost")
+	opts.Auth.Mechanism = "MONGODB-AWS"
+	if err := (&SSLDBConnector{}).Configure(opts); err == nil {
+		t.Errorf("Expected MONGODB-AWS authentication to be rejected")
+	}
+}
+
+func TestConfigureDHParamsFile(t *testing.T) {
+	opts := testOptions("localhost")
+	opts.SSLDHParamsFile = "dhparams.pem"
+	if err := (&SSLDBConnector{}).Configure(opts); err == nil {
+		t.Errorf("Expected DH parameters to be rejected")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/10gen/openssl"
+)
+
+// toX509 converts a certificate from the openssl bindings into its
+// crypto/x509 form, which exposes the parsed extensions.
+func toX509(cert *openssl.Certificate) (*x509.Certificate, error) {
+	data, err := cert.MarshalPEM()
+	if err != nil {
+		return nil, fmt.Errorf("MarshalPEM: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode certificate PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// peerCertificate returns the certificate presented by the server on conn.
+func peerCertificate(conn *openssl.Conn) (*x509.Certificate, error) {
+	cert, err := conn.PeerCertificate()
+	if err != nil {
+		return nil, err
+	}
+	return toX509(cert)
+}
+
+// verifyServerName checks that the certificate presented on conn was issued
+// for host.
+func verifyServerName(conn *openssl.Conn, host string) error {
+	cert, err := conn.PeerCertificate()
+	if err != nil {
+		return err
+	}
+	return verifyCertName(cert, host)
+}
+
+// verifyCertName checks that cert was issued for host. If host is an IP
+// address it must match one of the certificate's IP address SANs;
+// cert.VerifyHostname can't be used for those since it compares IPv4
+// addresses in their 16 byte form, which never matches.
+func verifyCertName(cert *openssl.Certificate, host string) error {
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	if ip == nil {
+		return cert.VerifyHostname(host)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if err := cert.CheckIP(ip, 0); err != nil {
+		return fmt.Errorf("server certificate is not valid for IP address %v: %v", host, err)
+	}
+	return nil
+}
+
+// protocolForCipher returns the protocol version implied by a negotiated
+// cipher name, or the empty string if the name doesn't determine it. Only
+// TLS 1.3 ciphersuites are named with a "TLS_" prefix by OpenSSL.
+func protocolForCipher(cipher string) string {
+	if strings.HasPrefix(cipher, "TLS_") {
+		return "TLSv1.3"
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	"github.com/10gen/openssl"
+)
+
+func TestVerifyServerName(t *testing.T) {
+	dir, cleanup := testDir(t, "peer")
+	defer cleanup()
+
+	ca := newTestCA(t, "Name Test CA")
+	caFile := pemFile(t, dir, "ca.pem", ca)
+	matching, closeMatching := tlsServer(t, newServerCert(t, "matching", ca))
+	defer closeMatching()
+	other, closeOther := tlsServer(t, newTestCert(t, "other", x509.Certificate{IPAddresses: []net.IP{net.ParseIP("10.0.0.1")}}, ca))
+	defer closeOther()
+
+	cases := []struct {
+		Name    string
+		Address string
+		Valid   bool
+	}{
+		{Name: "matching IP address SAN", Address: matching, Valid: true},
+		{Name: "other IP address SAN", Address: other},
+	}
+
+	for _, v := range cases {
+		connector := localConnector(t, v.Address, caFile, nil)
+		conn, err := connector.dial(v.Address)
+		if err == nil {
+			conn.Close()
+		}
+		connector.Close()
+		if (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+	}
+}
+
+func TestVerifyCertName(t *testing.T) {
+	ca := newTestCA(t, "Name Test CA")
+	issued := newTestCert(t, "server", x509.Certificate{
+		DNSNames:    []string{"db.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1")},
+	}, ca)
+	cert, err := openssl.LoadCertificateFromPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issued.cert.Raw}))
+	if err != nil {
+		t.Fatalf("Error loading certificate: %v", err)
+	}
+
+	cases := []struct {
+		Name  string
+		Host  string
+		Valid bool
+	}{
+		{Name: "DNS name", Host: "db.example.com", Valid: true},
+		{Name: "other DNS name", Host: "other.example.com"},
+		{Name: "IPv4 address", Host: "10.0.0.1", Valid: true},
+		{Name: "other IPv4 address", Host: "10.0.0.2"},
+		{Name: "IPv6 address", Host: "fd00::1", Valid: true},
+		{Name: "bracketed IPv6 address", Host: "[fd00::1]", Valid: true},
+		{Name: "other IPv6 address", Host: "fd00::2"},
+	}
+
+	for _, v := range cases {
+		if err := verifyCertName(cert, v.Host); (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+	}
+}
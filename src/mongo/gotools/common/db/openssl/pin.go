@@ -0,0 +1,80 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/mongodb/mongo-tools/common/log"
+	"gopkg.in/mgo.v2"
+)
+
+// defaultMemberPort is the port assumed for a pinned member given without one.
+const defaultMemberPort = "27017"
+
+// normalizeMember returns member as a host:port address, since replica set
+// configurations always name a port.
+func normalizeMember(member string) string {
+	if _, _, err := net.SplitHostPort(member); err == nil {
+		return member
+	}
+	return net.JoinHostPort(strings.Trim(member, "[]"), defaultMemberPort)
+}
+
+// dialPinned discovers the replica set from the seeds in dialInfo and then
+// connects a session directly to member, which must be one of its hosts and
+// a healthy primary or secondary. Every operation on the session goes to
+// member, and fails rather than being routed elsewhere if member becomes
+// unavailable.
+func dialPinned(dialInfo *mgo.DialInfo, member string) (*mgo.Session, error) {
+	discovery, err := mgo.DialWithInfo(dialInfo)
+	if err != nil {
+		return nil, err
+	}
+	// isMaster may go to any member; in strong mode it would fail while the
+	// set has no primary, even if member is available
+	discovery.SetMode(mgo.Monotonic, true)
+	var result isMasterResult
+	err = discovery.Run("isMaster", &result)
+	discovery.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error running isMaster: %v", err)
+	}
+	if err = checkMember(result, member); err != nil {
+		return nil, err
+	}
+
+	// the candidate's session is direct and in monotonic mode, so it can
+	// read from member even if it's a secondary
+	candidate := dialCandidate(dialInfo, member)
+	if candidate.err != nil {
+		return nil, fmt.Errorf("error connecting to pinned member %v: %v", member, candidate.err)
+	}
+	log.Logvf(log.DebugLow, "pinned session to member %v of replica set %v", member, result.SetName)
+	return candidate.session, nil
+}
+
+// checkMember returns an error unless result, taken from isMaster, lists
+// member as one of the hosts of its replica set.
+func checkMember(result isMasterResult, member string) error {
+	if result.SetName == "" {
+		return fmt.Errorf("sessions can only be pinned to a member of a replica set")
+	}
+	// priority 0 members, commonly used for backups, are listed separately
+	members := append(append([]string(nil), result.Hosts...), result.Passives...)
+	for _, host := range members {
+		if host == member {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v is not a member of replica set %v (members: %v)",
+		member, result.SetName, strings.Join(members, ", "))
+}
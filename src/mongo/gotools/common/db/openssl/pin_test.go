@@ -0,0 +1,61 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+)
+
+func TestNormalizeMember(t *testing.T) {
+	cases := []struct {
+		Member   string
+		Expected string
+	}{
+		{Member: "db1.example.com:27018", Expected: "db1.example.com:27018"},
+		{Member: "db1.example.com", Expected: "db1.example.com:27017"},
+		{Member: "::1", Expected: "[::1]:27017"},
+		{Member: "[::1]", Expected: "[::1]:27017"},
+		{Member: "[::1]:27018", Expected: "[::1]:27018"},
+	}
+
+	for _, v := range cases {
+		if got := normalizeMember(v.Member); got != v.Expected {
+			t.Errorf("normalizeMember(%q) = %q, expected %q", v.Member, got, v.Expected)
+		}
+	}
+}
+
+func TestCheckMember(t *testing.T) {
+	set := isMasterResult{
+		SetName:  "rs0",
+		Hosts:    []string{"a:27017", "b:27017"},
+		Passives: []string{"backup:27017"},
+		Arbiters: []string{"arb:27017"},
+	}
+	cases := []struct {
+		Result isMasterResult
+		Member string
+		Valid  bool
+	}{
+		{Result: set, Member: "a:27017", Valid: true},
+		{Result: set, Member: "backup:27017", Valid: true},
+		{Result: set, Member: "arb:27017", Valid: false},
+		{Result: set, Member: "c:27017", Valid: false},
+		{Result: isMasterResult{IsMaster: true}, Member: "a:27017", Valid: false},
+	}
+
+	for _, v := range cases {
+		err := checkMember(v.Result, v.Member)
+		if v.Valid && err != nil {
+			t.Errorf("Error checking %v: %v", v.Member, err)
+		} else if !v.Valid && err == nil {
+			t.Errorf("Expected an error checking %v but it was accepted", v.Member)
+		}
+	}
+}
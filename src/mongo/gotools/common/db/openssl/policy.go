@@ -0,0 +1,127 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/10gen/openssl"
+)
+
+// anyPolicy is the special policy an issuing certificate can assert to
+// accept every policy of the certificates below it.
+var anyPolicy = asn1.ObjectIdentifier{2, 5, 29, 32, 0}
+
+// parsePolicyOIDs parses dotted decimal certificate policy OIDs.
+func parsePolicyOIDs(oids []string) ([]asn1.ObjectIdentifier, error) {
+	parsed := make([]asn1.ObjectIdentifier, 0, len(oids))
+	for _, oid := range oids {
+		arcs := strings.Split(oid, ".")
+		if len(arcs) < 2 {
+			return nil, fmt.Errorf("invalid certificate policy OID '%v'", oid)
+		}
+		identifier := make(asn1.ObjectIdentifier, len(arcs))
+		for i, arc := range arcs {
+			n, err := strconv.Atoi(arc)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid certificate policy OID '%v'", oid)
+			}
+			identifier[i] = n
+		}
+		parsed = append(parsed, identifier)
+	}
+	return parsed, nil
+}
+
+// checkCertificatePolicies returns an error naming the first of required
+// that the verified chain of the server presented on conn doesn't assert.
+func checkCertificatePolicies(conn *openssl.Conn, roots *x509.CertPool, required []asn1.ObjectIdentifier) error {
+	chain, err := conn.PeerCertificateChain()
+	if err != nil {
+		return fmt.Errorf("error getting the server's certificate chain: %v", err)
+	}
+	parsed := make([]*x509.Certificate, 0, len(chain))
+	for _, cert := range chain {
+		x509Cert, err := toX509(cert)
+		if err != nil {
+			return fmt.Errorf("error parsing the server's certificate chain: %v", err)
+		}
+		parsed = append(parsed, x509Cert)
+	}
+	if len(parsed) == 0 {
+		return fmt.Errorf("the server presented no certificates")
+	}
+	return checkChainPolicies(parsed, roots, required, time.Now())
+}
+
+// checkChainPolicies builds the chains from the server's certificate, the
+// first of presented, to roots through the rest of presented, and returns an
+// error unless one of them asserts every policy in required. That way the
+// certificates checked are the ones the chain is actually verified through,
+// including intermediates the server didn't send, rather than whatever the
+// server chose to present. The server's certificate must assert each policy,
+// and every issuing certificate must assert it too or assert anyPolicy. The
+// root each chain ends in is its trust anchor, so it isn't checked.
+func checkChainPolicies(presented []*x509.Certificate, roots *x509.CertPool, required []asn1.ObjectIdentifier, now time.Time) error {
+	verifyOpts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   now,
+		// usages were already checked by the handshake
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	for _, cert := range presented[1:] {
+		verifyOpts.Intermediates.AddCert(cert)
+	}
+	chains, err := presented[0].Verify(verifyOpts)
+	if err != nil {
+		return fmt.Errorf("error building the server's verified certificate chain: %v", err)
+	}
+
+	for _, chain := range chains {
+		if err = chainAssertsPolicies(chain, required); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// chainAssertsPolicies returns an error naming the first of required that
+// the verified chain doesn't assert.
+func chainAssertsPolicies(chain []*x509.Certificate, required []asn1.ObjectIdentifier) error {
+	checked := chain
+	if len(chain) > 1 {
+		checked = chain[:len(chain)-1]
+	}
+	for _, policy := range required {
+		for i, cert := range checked {
+			if !assertsPolicy(cert, policy, i > 0) {
+				return fmt.Errorf("certificate %v does not assert the required certificate policy %v",
+					cert.Subject, policy)
+			}
+		}
+	}
+	return nil
+}
+
+// assertsPolicy reports whether cert lists policy among its certificate
+// policies, or for an issuing certificate lists anyPolicy.
+func assertsPolicy(cert *x509.Certificate, policy asn1.ObjectIdentifier, issuing bool) bool {
+	for _, identifier := range cert.PolicyIdentifiers {
+		if identifier.Equal(policy) || (issuing && identifier.Equal(anyPolicy)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckChainPolicies(t *testing.T) {
+	required := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+	other := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 2}
+	issuing := func(policies ...asn1.ObjectIdentifier) x509.Certificate {
+		return x509.Certificate{
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+			PolicyIdentifiers:     policies,
+		}
+	}
+	leaf := func(policies ...asn1.ObjectIdentifier) x509.Certificate {
+		return x509.Certificate{PolicyIdentifiers: policies}
+	}
+
+	root := newTestCA(t, "Policy Test CA")
+	asserting := newTestCert(t, "asserting", issuing(required), root)
+	anyPolicyCA := newTestCert(t, "any policy", issuing(anyPolicy), root)
+	notAsserting := newTestCert(t, "not asserting", issuing(other), root)
+	untrusted := newTestCA(t, "Untrusted CA")
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	cases := []struct {
+		Name  string
+		Chain []*testCert
+		Error string
+	}{
+		{Name: "asserted throughout", Chain: []*testCert{newTestCert(t, "server", leaf(required), asserting), asserting}},
+		{Name: "any policy", Chain: []*testCert{newTestCert(t, "server", leaf(required), anyPolicyCA), anyPolicyCA}},
+		{Name: "server doesn't assert", Chain: []*testCert{newTestCert(t, "server", leaf(other), asserting), asserting},
+			Error: "CN=server does not assert"},
+		{Name: "intermediate doesn't assert", Chain: []*testCert{newTestCert(t, "server", leaf(required), notAsserting), notAsserting},
+			Error: "CN=not asserting does not assert"},
+		// only the chain the server's certificate is verified through counts
+		{Name: "unrelated certificate presented", Chain: []*testCert{newTestCert(t, "server", leaf(required), asserting), asserting, notAsserting}},
+		{Name: "intermediate not sent", Chain: []*testCert{newTestCert(t, "server", leaf(required), asserting)},
+			Error: "verified certificate chain"},
+		{Name: "untrusted", Chain: []*testCert{newTestCert(t, "server", leaf(required), untrusted), untrusted},
+			Error: "verified certificate chain"},
+	}
+
+	for _, v := range cases {
+		presented := make([]*x509.Certificate, len(v.Chain))
+		for i, cert := range v.Chain {
+			presented[i] = cert.cert
+		}
+		err := checkChainPolicies(presented, roots, []asn1.ObjectIdentifier{required}, time.Now())
+		switch {
+		case v.Error == "" && err != nil:
+			t.Errorf("%v: unexpected error: %v", v.Name, err)
+		case v.Error != "" && err == nil:
+			t.Errorf("%v: expected an error", v.Name)
+		case v.Error != "" && !strings.Contains(err.Error(), v.Error):
+			t.Errorf("%v: error should mention %q: %v", v.Name, v.Error, err)
+		}
+	}
+}
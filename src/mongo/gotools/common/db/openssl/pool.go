@@ -0,0 +1,144 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+// sharedSession is a master session shared by every connector configured
+// with the same connection and auth options.
+type sharedSession struct {
+	key string
+	// closed once the session has been dialed, after which session and err
+	// don't change
+	ready   chan struct{}
+	session *mgo.Session
+	err     error
+	// keeps the session alive, if an application keep-alive interval is
+	// configured, from when it's dialed until it's closed
+	pinger *sessionPinger
+
+	// guarded by sharedSessions
+	refs int
+}
+
+// sharedSessions holds the shared master sessions, keyed by sessionPoolKey.
+var sharedSessions = struct {
+	sync.Mutex
+	byKey map[string]*sharedSession
+}{byKey: map[string]*sharedSession{}}
+
+// sessionPoolKey hashes everything about the connector's configuration that
+// affects the sessions it builds: the dial info, the ssl options the ctx and
+// verification are set up from, and the socket settings, so that only
+// connectors which would have built identical sessions end up sharing one.
+// The write concern isn't included, since it's set on each copy.
+func (self *connectorConfig) sessionPoolKey() string {
+	dialInfo := self.dialInfo
+	var linger interface{}
+	if self.linger != nil {
+		linger = *self.linger
+	}
+	var readPreference interface{}
+	if dialInfo.ReadPreference != nil {
+		readPreference = *dialInfo.ReadPreference
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%q|%v|%v|%v|%q|%q|%q|%q|%q|%q|%q|%q|%v|%+v|%+v|",
+		dialInfo.Addrs, dialInfo.Direct, dialInfo.Timeout, dialInfo.FailFast,
+		dialInfo.Database, dialInfo.ReplicaSetName, dialInfo.Source, dialInfo.Service,
+		dialInfo.ServiceHost, dialInfo.Mechanism, dialInfo.Username, dialInfo.Password,
+		dialInfo.PoolLimit, readPreference, *self.opts.SSL)
+	fmt.Fprintf(hash, "%v|%v|%v|%v|%v|%v|%v",
+		self.flags, self.keepAlive, linger, self.readBuffer, self.writeBuffer,
+		self.maxMessageSize, self.appKeepAlive)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// acquireSharedSession returns the shared session for key, calling dial to
+// create it if no other connector holds it, and pinging it every keepAlive if
+// that's non-zero. Since keepAlive is part of the key, every connector holding
+// the session agrees on it. Dialing happens outside the lock on the shared
+// sessions, so connectors with other keys aren't held up, and concurrent
+// callers with the same key wait for the one dial. A failed dial isn't kept,
+// so the next caller dials again. Every successful call must be paired with a
+// call to releaseSharedSession.
+func acquireSharedSession(key string, keepAlive time.Duration, dial func() (*mgo.Session, error)) (*sharedSession, error) {
+	sharedSessions.Lock()
+	if shared, ok := sharedSessions.byKey[key]; ok {
+		shared.refs++
+		sharedSessions.Unlock()
+		<-shared.ready
+		if shared.err != nil {
+			// the dialer already removed it, so the reference doesn't matter
+			return nil, shared.err
+		}
+		return shared, nil
+	}
+	shared := &sharedSession{key: key, ready: make(chan struct{}), refs: 1}
+	sharedSessions.byKey[key] = shared
+	sharedSessions.Unlock()
+
+	shared.session, shared.err = dial()
+	if shared.err != nil {
+		sharedSessions.Lock()
+		delete(sharedSessions.byKey, key)
+		sharedSessions.Unlock()
+	}
+	if shared.err == nil && keepAlive > 0 {
+		shared.pinger = newSessionPinger(shared.session, keepAlive)
+	}
+	close(shared.ready)
+	if shared.err != nil {
+		return nil, shared.err
+	}
+	return shared, nil
+}
+
+// releaseSharedSession drops a reference to shared, closing its session
+// and stopping its pinger once no connector holds it any more.
+func releaseSharedSession(shared *sharedSession) {
+	sharedSessions.Lock()
+	shared.refs--
+	last := shared.refs == 0
+	if last {
+		delete(sharedSessions.byKey, shared.key)
+	}
+	sharedSessions.Unlock()
+	if !last {
+		return
+	}
+	// stopping the pinger waits for a ping in progress, so it's done outside
+	// the lock
+	if shared.pinger != nil {
+		shared.pinger.close()
+	}
+	shared.session.Close()
+}
+
+// poolConnector returns a connector with this one's configuration but not its
+// hooks, which makes the connections of the shared session. They belong to
+// every connector sharing it, so they aren't reported to the event sink or
+// counted in the metrics of whichever connector happened to dial it first.
+func (self *SSLDBConnector) poolConnector() *SSLDBConnector {
+	pool := &SSLDBConnector{connectorConfig: self.connectorConfig}
+	pool.errorFormatter = nil
+	pool.eventSink = nil
+	dialInfo := *self.dialInfo
+	pool.dialInfo = &dialInfo
+	pool.bindDialer()
+	return pool
+}
@@ -0,0 +1,123 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2"
+)
+
+func TestSessionPoolKey(t *testing.T) {
+	sharing := func(with func(opts *options.ToolOptions)) string {
+		connector := configuredConnector(t, "host1,host2", func(opts *options.ToolOptions) {
+			opts.ShareSessions = true
+			if with != nil {
+				with(opts)
+			}
+		})
+		defer connector.Close()
+		return connector.poolKey
+	}
+	linger := func(d time.Duration) *time.Duration { return &d }
+
+	base := sharing(nil)
+	if base == "" {
+		t.Fatalf("Sharing sessions should set a pool key")
+	}
+	if key := sharing(nil); key != base {
+		t.Errorf("Identically configured connectors should have the same pool key")
+	}
+	if key := sharing(func(opts *options.ToolOptions) { opts.WriteConcern = "1" }); key != base {
+		t.Errorf("The write concern, which is set on each copy, shouldn't change the pool key")
+	}
+
+	cases := []struct {
+		Name string
+		With func(opts *options.ToolOptions)
+	}{
+		{Name: "hosts", With: func(opts *options.ToolOptions) { opts.Host = "host1" }},
+		{Name: "user", With: func(opts *options.ToolOptions) { opts.Auth.Username = "user" }},
+		{Name: "password", With: func(opts *options.ToolOptions) {
+			opts.Auth.Username, opts.Auth.Password = "user", "secret"
+		}},
+		{Name: "replica set", With: func(opts *options.ToolOptions) { opts.ReplicaSetName = "rs0" }},
+		{Name: "direct", With: func(opts *options.ToolOptions) { opts.Direct = true }},
+		{Name: "ssl options", With: func(opts *options.ToolOptions) { opts.SSLAllowInvalidHost = true }},
+		{Name: "TCP keep-alive", With: func(opts *options.ToolOptions) { opts.TCPKeepAliveSeconds = 30 }},
+		{Name: "linger", With: func(opts *options.ToolOptions) { opts.SocketLinger = linger(0) }},
+		{Name: "read buffer", With: func(opts *options.ToolOptions) { opts.SocketReadBuffer = 1 << 20 }},
+		{Name: "maximum message size", With: func(opts *options.ToolOptions) { opts.MaxMessageSizeBytes = 1 << 20 }},
+		{Name: "application keep-alive", With: func(opts *options.ToolOptions) { opts.AppKeepAliveInterval = time.Minute }},
+	}
+	for _, v := range cases {
+		if key := sharing(v.With); key == base {
+			t.Errorf("Changing the %v should change the pool key", v.Name)
+		}
+	}
+}
+
+func TestAcquireSharedSessionDialsOnce(t *testing.T) {
+	key := fmt.Sprintf("test-%v", time.Now().UnixNano())
+	var dials int32
+	release := make(chan struct{})
+	dial := func() (*mgo.Session, error) {
+		atomic.AddInt32(&dials, 1)
+		<-release
+		return nil, fmt.Errorf("no servers")
+	}
+
+	const callers = 4
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := acquireSharedSession(key, 0, dial)
+			errs <- err
+		}()
+	}
+	// let every caller find the dial in progress before it fails
+	for {
+		sharedSessions.Lock()
+		shared := sharedSessions.byKey[key]
+		waiting := shared != nil && shared.refs == callers
+		sharedSessions.Unlock()
+		if waiting {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err == nil {
+			t.Errorf("Expected the dial's error")
+		}
+	}
+	if n := atomic.LoadInt32(&dials); n != 1 {
+		t.Errorf("Concurrent callers dialed %v times, expected once", n)
+	}
+
+	// a failed dial isn't kept, so the next caller dials again
+	if _, err := acquireSharedSession(key, 0, dial); err == nil {
+		t.Errorf("Expected the dial's error")
+	}
+	if n := atomic.LoadInt32(&dials); n != 2 {
+		t.Errorf("Dialed %v times after a failure, expected twice", n)
+	}
+}
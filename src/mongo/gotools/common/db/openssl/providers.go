@@ -0,0 +1,40 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func init() {
+	sslInitializationFunctions = append(sslInitializationFunctions, LoadProviderOptions)
+}
+
+// LoadProviderOptions handles the OpenSSL config file and provider options.
+// The openssl bindings load the default config once at startup and don't wrap
+// CONF_modules_load_file or OSSL_PROVIDER_load, so neither can be loaded
+// later; the config file named by the OPENSSL_CONF environment variable,
+// which can activate providers, is the only way to configure them.
+func LoadProviderOptions(opts options.ToolOptions) error {
+	if opts.SSLConf != "" {
+		if _, err := os.Stat(opts.SSLConf); err != nil {
+			return fmt.Errorf("error reading OpenSSL config file: %v", err)
+		}
+		return fmt.Errorf("loading an OpenSSL config file is not supported by this build; "+
+			"set the OPENSSL_CONF environment variable to %v instead", opts.SSLConf)
+	}
+	if opts.SSLProvider != "" {
+		return fmt.Errorf("loading OpenSSL provider '%v' is not supported by this build; "+
+			"activate it in the config file named by the OPENSSL_CONF environment variable instead", opts.SSLProvider)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// connectionStatus is the part of the connectionStatus command's reply that
+// describes the authenticated identity.
+type connectionStatus struct {
+	AuthInfo struct {
+		AuthenticatedUsers []struct {
+			User string `bson:"user"`
+			DB   string `bson:"db"`
+		} `bson:"authenticatedUsers"`
+		AuthenticatedUserRoles []struct {
+			Role string `bson:"role"`
+			DB   string `bson:"db"`
+		} `bson:"authenticatedUserRoles"`
+	} `bson:"authInfo"`
+}
+
+// AuthenticatedRoles connects to the server and returns the roles granted to
+// the identity the connector authenticates as, each as "<role>@<db>". The
+// server may be any member, including a secondary connected to directly. It
+// must be called after Configure.
+func (self *SSLDBConnector) AuthenticatedRoles() ([]string, error) {
+	session, err := self.GetNewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	// any member can answer, including a secondary connected to directly
+	session.SetMode(mgo.Monotonic, true)
+
+	var status connectionStatus
+	if err = session.Run(bson.D{{Name: "connectionStatus", Value: 1}}, &status); err != nil {
+		return nil, self.phaseError(phaseCommand, fmt.Errorf("error running connectionStatus: %v", err))
+	}
+	if len(status.AuthInfo.AuthenticatedUsers) == 0 {
+		return nil, self.phaseError(phaseCommand, fmt.Errorf("the connection is not authenticated"))
+	}
+	roles := make([]string, 0, len(status.AuthInfo.AuthenticatedUserRoles))
+	for _, role := range status.AuthInfo.AuthenticatedUserRoles {
+		roles = append(roles, role.Role+"@"+role.DB)
+	}
+	return roles, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// connectionStatusReply answers connectionStatus with authInfo, and every
+// other command as a standalone mongod.
+func connectionStatusReply(authInfo bson.M) wireReply {
+	return func(database string, command bson.D) bson.M {
+		if command[0].Name == "connectionStatus" {
+			return bson.M{"authInfo": authInfo, "ok": 1}
+		}
+		return mongodReply(database, command)
+	}
+}
+
+func TestAuthenticatedRoles(t *testing.T) {
+	dir, cleanup := testDir(t, "roles")
+	defer cleanup()
+
+	ca := newTestCA(t, "Roles Test CA")
+	server := newServerCert(t, "server", ca)
+	caFile := pemFile(t, dir, "ca.pem", ca)
+	authenticated := bson.M{
+		"authenticatedUsers": []bson.M{{"user": "alice", "db": "admin"}},
+		"authenticatedUserRoles": []bson.M{
+			{"role": "read", "db": "app"},
+			{"role": "clusterMonitor", "db": "admin"},
+		},
+	}
+
+	cases := []struct {
+		Name  string
+		Reply wireReply
+		Roles []string
+	}{
+		{Name: "authenticated", Reply: connectionStatusReply(authenticated),
+			Roles: []string{"read@app", "clusterMonitor@admin"}},
+		{Name: "authenticated without roles", Reply: connectionStatusReply(bson.M{
+			"authenticatedUsers": []bson.M{{"user": "alice", "db": "admin"}},
+		}), Roles: []string{}},
+		{Name: "not authenticated", Reply: connectionStatusReply(bson.M{
+			"authenticatedUsers": []bson.M{}, "authenticatedUserRoles": []bson.M{},
+		})},
+		{Name: "command fails", Reply: func(database string, command bson.D) bson.M {
+			if command[0].Name == "connectionStatus" {
+				return bson.M{"ok": 0, "errmsg": "no such command"}
+			}
+			return mongodReply(database, command)
+		}},
+		{Name: "secondary connected to directly", Reply: secondaryReply(connectionStatusReply(authenticated)),
+			Roles: []string{"read@app", "clusterMonitor@admin"}},
+	}
+
+	for _, v := range cases {
+		address, closeServer := tlsWireServer(t, server, v.Reply)
+		connector := localConnector(t, address, caFile, func(opts *options.ToolOptions) {
+			opts.Timeout = 1
+			opts.Direct = true
+		})
+		roles, err := connector.AuthenticatedRoles()
+		connector.Close()
+		closeServer()
+		if v.Roles == nil {
+			if err == nil {
+				t.Errorf("%v: expected an error, got roles %v", v.Name, roles)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", v.Name, err)
+		} else if !reflect.DeepEqual(roles, v.Roles) {
+			t.Errorf("%v: roles are %v, expected %v", v.Name, roles, v.Roles)
+		}
+	}
+}
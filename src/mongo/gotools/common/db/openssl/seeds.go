@@ -0,0 +1,41 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/mongodb/mongo-tools/common/log"
+)
+
+// resolvableAddrs returns the addresses whose hostnames resolve, logging a
+// warning for each one that doesn't. Temporary DNS failures don't count as
+// unresolvable, so those addresses are kept. It returns an error if no address
+// is left.
+func resolvableAddrs(addrs []string) ([]string, error) {
+	resolvable := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		if _, err = net.LookupHost(host); err != nil {
+			if dnsErr, ok := err.(*net.DNSError); ok && !dnsErr.Temporary() {
+				log.Logvf(log.Always, "WARNING: skipping seed host %v, which can't be resolved: %v", addr, err)
+				continue
+			}
+		}
+		resolvable = append(resolvable, addr)
+	}
+	if len(resolvable) == 0 {
+		return nil, fmt.Errorf("none of the seed hosts %v can be resolved", addrs)
+	}
+	return resolvable, nil
+}
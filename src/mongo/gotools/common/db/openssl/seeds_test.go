@@ -0,0 +1,51 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestResolvableAddrs(t *testing.T) {
+	// names under .invalid never resolve, but without a working resolver the
+	// failure is temporary and the address is kept
+	if _, err := net.LookupHost("seed.invalid"); err != nil {
+		if dnsErr, ok := err.(*net.DNSError); !ok || dnsErr.Temporary() {
+			t.Skipf("DNS isn't available: %v", err)
+		}
+	}
+
+	cases := []struct {
+		Name       string
+		Addrs      []string
+		Resolvable []string
+	}{
+		{Name: "all resolvable", Addrs: []string{"localhost:27017", "127.0.0.1:27018"}, Resolvable: []string{"localhost:27017", "127.0.0.1:27018"}},
+		{Name: "one unresolvable", Addrs: []string{"gone.invalid:27017", "localhost:27017"}, Resolvable: []string{"localhost:27017"}},
+		{Name: "address without a port", Addrs: []string{"gone.invalid", "localhost"}, Resolvable: []string{"localhost"}},
+		{Name: "none resolvable", Addrs: []string{"gone.invalid:27017", "also-gone.invalid:27017"}},
+	}
+
+	for _, v := range cases {
+		resolvable, err := resolvableAddrs(v.Addrs)
+		if v.Resolvable == nil {
+			if err == nil {
+				t.Errorf("%v: expected an error, got %v", v.Name, resolvable)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", v.Name, err)
+		} else if !reflect.DeepEqual(resolvable, v.Resolvable) {
+			t.Errorf("%v: resolvable addresses are %v, expected %v", v.Name, resolvable, v.Resolvable)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+)
+
+// connTracker keeps track of the connections a connector has handed to mgo
+// that are still open, so that they can be closed when the connector is.
+type connTracker struct {
+	mu      sync.Mutex
+	conns   map[*trackedConn]struct{}
+	drained chan struct{}
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{conns: map[*trackedConn]struct{}{}}
+}
+
+// trackedConn removes itself from its tracker when it is closed.
+type trackedConn struct {
+	*openssl.Conn
+	tracker *connTracker
+	once    sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(func() { c.tracker.remove(c) })
+	return c.Conn.Close()
+}
+
+// forceClose closes the connection even if another goroutine is blocked
+// reading from it. The ssl shutdown waits for any read in progress, so the
+// socket is closed first to make that read fail.
+func (c *trackedConn) forceClose() {
+	c.UnderlyingConn().Close()
+	c.Close()
+}
+
+// track returns conn wrapped so that it is tracked until it is closed.
+func (t *connTracker) track(conn *openssl.Conn) net.Conn {
+	tracked := &trackedConn{Conn: conn, tracker: t}
+	t.mu.Lock()
+	t.conns[tracked] = struct{}{}
+	t.mu.Unlock()
+	return tracked
+}
+
+// remove stops tracking conn.
+func (t *connTracker) remove(conn *trackedConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, conn)
+	if len(t.conns) == 0 && t.drained != nil {
+		close(t.drained)
+		t.drained = nil
+	}
+}
+
+// drain waits up to grace for mgo to close every tracked connection, which it
+// does once all sessions using them have been closed, then closes any that
+// are still open.
+func (t *connTracker) drain(grace time.Duration) {
+	t.mu.Lock()
+	if len(t.conns) == 0 {
+		t.mu.Unlock()
+		return
+	}
+	if t.drained == nil {
+		t.drained = make(chan struct{})
+	}
+	drained := t.drained
+	t.mu.Unlock()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-drained:
+		return
+	case <-timer.C:
+	}
+
+	t.mu.Lock()
+	remaining := make([]*trackedConn, 0, len(t.conns))
+	for conn := range t.conns {
+		remaining = append(remaining, conn)
+	}
+	t.mu.Unlock()
+	log.Logvf(log.Always, "closing %v connections still in use after the %v shutdown grace period",
+		len(remaining), grace)
+	for _, conn := range remaining {
+		conn.forceClose()
+	}
+}
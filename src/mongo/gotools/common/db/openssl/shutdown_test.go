@@ -0,0 +1,84 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestShutdownGrace(t *testing.T) {
+	dir, cleanup := testDir(t, "shutdown")
+	defer cleanup()
+
+	ca := newTestCA(t, "Shutdown Test CA")
+	server, closeServer := tlsServer(t, newServerCert(t, "server", ca))
+	defer closeServer()
+	caFile := pemFile(t, dir, "ca.pem", ca)
+
+	cases := []struct {
+		Name     string
+		Grace    time.Duration
+		CloseIn  time.Duration
+		MinClose time.Duration
+		MaxClose time.Duration
+		Forced   bool
+	}{
+		{Name: "released within the grace period", Grace: 10 * time.Second, CloseIn: 50 * time.Millisecond, MaxClose: 5 * time.Second},
+		{Name: "still in use after the grace period", Grace: 200 * time.Millisecond, MinClose: 200 * time.Millisecond, MaxClose: 5 * time.Second, Forced: true},
+	}
+
+	for _, v := range cases {
+		connector := localConnector(t, server, caFile, func(opts *options.ToolOptions) {
+			opts.ShutdownGrace = v.Grace
+		})
+		conn, err := connector.dial(server)
+		if err != nil {
+			t.Fatalf("%v: error dialing: %v", v.Name, err)
+		}
+		wrapped := connector.serverConn(conn)
+		if v.CloseIn > 0 {
+			time.AfterFunc(v.CloseIn, func() { wrapped.Close() })
+		}
+
+		start := time.Now()
+		connector.Close()
+		elapsed := time.Since(start)
+		if elapsed < v.MinClose || elapsed > v.MaxClose {
+			t.Errorf("%v: Close took %v, expected between %v and %v", v.Name, elapsed, v.MinClose, v.MaxClose)
+		}
+		connector.tracker.mu.Lock()
+		if open := len(connector.tracker.conns); open != 0 {
+			t.Errorf("%v: %v connections still tracked after Close", v.Name, open)
+		}
+		connector.tracker.mu.Unlock()
+		if !v.Forced {
+			continue
+		}
+		if _, err := conn.UnderlyingConn().Write([]byte{0}); err == nil {
+			t.Errorf("%v: connection still open after Close", v.Name)
+		}
+	}
+}
+
+func TestShutdownGraceWithoutConnections(t *testing.T) {
+	tracker := newConnTracker()
+	done := make(chan struct{})
+	go func() {
+		tracker.drain(time.Minute)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Draining a tracker with no connections should return at once")
+	}
+}
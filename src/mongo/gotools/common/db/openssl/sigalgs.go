@@ -0,0 +1,64 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The signature schemes defined by RFC 8446, by the names OpenSSL accepts.
+var signatureSchemes = map[string]bool{
+	"rsa_pkcs1_sha1":         true,
+	"rsa_pkcs1_sha256":       true,
+	"rsa_pkcs1_sha384":       true,
+	"rsa_pkcs1_sha512":       true,
+	"ecdsa_sha1":             true,
+	"ecdsa_secp256r1_sha256": true,
+	"ecdsa_secp384r1_sha384": true,
+	"ecdsa_secp521r1_sha512": true,
+	"rsa_pss_rsae_sha256":    true,
+	"rsa_pss_rsae_sha384":    true,
+	"rsa_pss_rsae_sha512":    true,
+	"rsa_pss_pss_sha256":     true,
+	"rsa_pss_pss_sha384":     true,
+	"rsa_pss_pss_sha512":     true,
+	"ed25519":                true,
+	"ed448":                  true,
+}
+
+// The public key and digest algorithms that can be combined as
+// "<algorithm>+<digest>" in an OpenSSL signature algorithm list.
+var (
+	signatureKeyAlgorithms    = map[string]bool{"RSA": true, "RSA-PSS": true, "DSA": true, "ECDSA": true}
+	signatureDigestAlgorithms = map[string]bool{"SHA1": true, "SHA224": true, "SHA256": true, "SHA384": true, "SHA512": true}
+)
+
+// validateSignatureAlgorithms checks that a colon-separated signature
+// algorithm list is non-empty and only names known signature schemes or
+// algorithm and digest pairs.
+func validateSignatureAlgorithms(list string) error {
+	var count int
+	for _, name := range strings.Split(list, ":") {
+		if name == "" {
+			continue
+		}
+		if !signatureSchemes[name] {
+			parts := strings.Split(name, "+")
+			if len(parts) != 2 || !signatureKeyAlgorithms[parts[0]] || !signatureDigestAlgorithms[parts[1]] {
+				return fmt.Errorf("unknown signature algorithm '%v'", name)
+			}
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("signature algorithm list '%v' is empty", list)
+	}
+	return nil
+}
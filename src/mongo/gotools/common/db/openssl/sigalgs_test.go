@@ -0,0 +1,38 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+)
+
+func TestValidateSignatureAlgorithms(t *testing.T) {
+	cases := []struct {
+		List  string
+		Valid bool
+	}{
+		{List: "rsa_pss_rsae_sha256", Valid: true},
+		{List: "ecdsa_secp256r1_sha256:ed25519", Valid: true},
+		{List: "ECDSA+SHA256:RSA-PSS+SHA384", Valid: true},
+		{List: "rsa_pss_rsae_sha256:ECDSA+SHA256:", Valid: true},
+		{List: ""},
+		{List: ":"},
+		{List: "rsa_pss_rsae_sha1"},
+		{List: "ECDSA+MD5"},
+		{List: "EdDSA+SHA256"},
+		{List: "ECDSA+SHA256+SHA384"},
+		{List: "ECDSA"},
+	}
+
+	for _, v := range cases {
+		if err := validateSignatureAlgorithms(v.List); (err == nil) != v.Valid {
+			t.Errorf("%q: valid is %v, expected %v: %v", v.List, err == nil, v.Valid, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/connstring"
+	"github.com/mongodb/mongo-tools/common/log"
+)
+
+// minSRVPollInterval is the shortest interval between SRV lookups allowed by
+// the drivers' SRV polling spec.
+const minSRVPollInterval = 60 * time.Second
+
+// srvPoller keeps the seed list looked up from an SRV record current.
+type srvPoller struct {
+	host     string
+	interval time.Duration
+
+	mu    sync.Mutex
+	addrs []string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newSRVPoller starts looking up the SRV record of host every interval,
+// starting from the seed list addrs.
+func newSRVPoller(host string, addrs []string, interval time.Duration) *srvPoller {
+	p := &srvPoller{
+		host:     host,
+		interval: interval,
+		addrs:    addrs,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// current returns the most recently looked up seed list.
+func (p *srvPoller) current() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.addrs
+}
+
+// close stops polling and waits for a lookup in progress to finish.
+func (p *srvPoller) close() {
+	close(p.stop)
+	<-p.done
+}
+
+func (p *srvPoller) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.poll()
+		}
+	}
+}
+
+// poll looks up the SRV record once. The previous seed list is kept if the
+// lookup fails or returns no hosts, as the spec requires.
+func (p *srvPoller) poll() {
+	addrs, err := connstring.FetchSeedlistFromSRV(p.host)
+	if err != nil {
+		log.Logvf(log.DebugLow, "error looking up SRV record of %v, keeping the current seed list: %v", p.host, err)
+		return
+	}
+	if len(addrs) == 0 {
+		log.Logvf(log.DebugLow, "SRV record of %v lists no hosts, keeping the current seed list", p.host)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !sameAddrs(p.addrs, addrs) {
+		log.Logvf(log.Info, "seed list from SRV record of %v changed from %v to %v", p.host, p.addrs, addrs)
+		p.addrs = addrs
+	}
+}
+
+// sameAddrs reports whether a and b contain the same addresses, in any order.
+func sameAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int, len(a))
+	for _, addr := range a {
+		seen[addr]++
+	}
+	for _, addr := range b {
+		if seen[addr] == 0 {
+			return false
+		}
+		seen[addr]--
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+func TestSameAddrs(t *testing.T) {
+	cases := []struct {
+		Name string
+		A, B []string
+		Same bool
+	}{
+		{Name: "same order", A: []string{"a:1", "b:1"}, B: []string{"a:1", "b:1"}, Same: true},
+		{Name: "other order", A: []string{"a:1", "b:1"}, B: []string{"b:1", "a:1"}, Same: true},
+		{Name: "host added", A: []string{"a:1"}, B: []string{"a:1", "b:1"}},
+		{Name: "host replaced", A: []string{"a:1", "b:1"}, B: []string{"a:1", "c:1"}},
+		{Name: "duplicates", A: []string{"a:1", "a:1"}, B: []string{"a:1", "b:1"}},
+	}
+
+	for _, v := range cases {
+		if same := sameAddrs(v.A, v.B); same != v.Same {
+			t.Errorf("%v: same is %v, expected %v", v.Name, same, v.Same)
+		}
+	}
+}
+
+func TestSRVPollerKeepsSeedListOnFailure(t *testing.T) {
+	seeds := []string{"a.example.com:27017", "b.example.com:27017"}
+	// names under .invalid never resolve
+	poller := &srvPoller{host: "cluster.invalid", addrs: seeds}
+	poller.poll()
+	if !reflect.DeepEqual(poller.current(), seeds) {
+		t.Errorf("Seed list is %v after a failed lookup, expected %v", poller.current(), seeds)
+	}
+}
+
+func TestSRVPollerClose(t *testing.T) {
+	poller := newSRVPoller("cluster.invalid", []string{"a.example.com:27017"}, time.Hour)
+	closed := make(chan struct{})
+	go func() {
+		poller.close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Closing should stop the poller")
+	}
+}
+
+func TestCurrentDialInfo(t *testing.T) {
+	connector := &SSLDBConnector{}
+	connector.dialInfo = &mgo.DialInfo{Addrs: []string{"a.example.com:27017"}, Timeout: time.Second}
+	if connector.currentDialInfo() != connector.dialInfo {
+		t.Errorf("Expected the configured dial info without SRV polling")
+	}
+
+	connector.srv = &srvPoller{addrs: []string{"b.example.com:27017"}}
+	dialInfo := connector.currentDialInfo()
+	if !reflect.DeepEqual(dialInfo.Addrs, []string{"b.example.com:27017"}) || dialInfo.Timeout != time.Second {
+		t.Errorf("Expected the configured dial info with the polled seed list: %+v", dialInfo)
+	}
+	if connector.dialInfo.Addrs[0] != "a.example.com:27017" {
+		t.Errorf("The configured dial info shouldn't change: %+v", connector.dialInfo)
+	}
+}
+
+func TestSRVPollIntervalRequiresSRV(t *testing.T) {
+	opts := testOptions("localhost")
+	opts.SRVPollInterval = minSRVPollInterval
+	if err := (&SSLDBConnector{}).Configure(opts); err == nil {
+		t.Errorf("Expected SRV polling to require a mongodb+srv connection string")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/log"
+	"gopkg.in/mgo.v2"
+)
+
+const (
+	// how often the standby session is pinged to check it's still usable,
+	// unless an application keep-alive interval is configured
+	standbyCheckInterval = 10 * time.Second
+	// the delay before retrying a failed connection, doubling on each
+	// failure up to the maximum
+	standbyMinBackoff = time.Second
+	standbyMaxBackoff = 30 * time.Second
+)
+
+// warmStandby keeps an established session ready in the background, so that
+// taking it doesn't wait for connecting and authenticating. Once taken, the
+// session is replaced with a new one.
+type warmStandby struct {
+	dial     func() (*mgo.Session, error)
+	interval time.Duration
+
+	mu      sync.Mutex
+	session *mgo.Session
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newWarmStandby starts maintaining a session established with dial, pinging
+// it every interval.
+func newWarmStandby(dial func() (*mgo.Session, error), interval time.Duration) *warmStandby {
+	s := &warmStandby{
+		dial:     dial,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *warmStandby) run() {
+	defer close(s.done)
+	backoff := standbyMinBackoff
+	for {
+		s.mu.Lock()
+		session := s.session
+		s.mu.Unlock()
+
+		wait := s.interval
+		if session == nil {
+			var err error
+			if session, err = s.dial(); err != nil {
+				log.Logvf(log.DebugLow, "error establishing standby session, retrying in %v: %v", backoff, err)
+				wait = backoff
+				if backoff *= 2; backoff > standbyMaxBackoff {
+					backoff = standbyMaxBackoff
+				}
+			} else {
+				backoff = standbyMinBackoff
+				s.mu.Lock()
+				s.session = session
+				s.mu.Unlock()
+			}
+		} else if err := session.Ping(); err != nil {
+			log.Logvf(log.DebugLow, "standby session failed, reconnecting: %v", err)
+			s.mu.Lock()
+			// it may have been taken while being pinged
+			if s.session == session {
+				s.session = nil
+				session.Close()
+			}
+			s.mu.Unlock()
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.wake:
+		case <-timer.C:
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+		timer.Stop()
+	}
+}
+
+// take returns the standby session, or nil if one isn't ready, and starts
+// establishing its replacement.
+func (s *warmStandby) take() *mgo.Session {
+	s.mu.Lock()
+	session := s.session
+	s.session = nil
+	s.mu.Unlock()
+	if session != nil {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return session
+}
+
+// close stops maintaining the standby session and closes it.
+func (s *warmStandby) close() {
+	close(s.stop)
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.session != nil {
+		s.session.Close()
+		s.session = nil
+	}
+}
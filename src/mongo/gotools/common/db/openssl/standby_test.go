@@ -0,0 +1,77 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+func TestWarmStandby(t *testing.T) {
+	address, closeServer := wireServer(t, mongodReply)
+	defer closeServer()
+
+	var dials int32
+	standby := newWarmStandby(func() (*mgo.Session, error) {
+		atomic.AddInt32(&dials, 1)
+		return mgo.DialWithInfo(&mgo.DialInfo{Addrs: []string{address}, Direct: true, Timeout: 5 * time.Second})
+	}, time.Minute)
+	defer standby.close()
+
+	ready := func() bool {
+		standby.mu.Lock()
+		defer standby.mu.Unlock()
+		return standby.session != nil
+	}
+	waitFor(t, 5*time.Second, "the standby session", ready)
+
+	session := standby.take()
+	if session == nil {
+		t.Fatalf("Expected the ready standby session")
+	}
+	if err := session.Ping(); err != nil {
+		t.Errorf("Error pinging the standby session: %v", err)
+	}
+	session.Close()
+
+	// taking the session starts establishing its replacement
+	waitFor(t, 5*time.Second, "the replacement standby session", ready)
+	if n := atomic.LoadInt32(&dials); n != 2 {
+		t.Errorf("Dialed %v times, expected twice", n)
+	}
+}
+
+func TestWarmStandbyRetries(t *testing.T) {
+	var dials int32
+	standby := newWarmStandby(func() (*mgo.Session, error) {
+		atomic.AddInt32(&dials, 1)
+		return nil, fmt.Errorf("no servers")
+	}, time.Minute)
+
+	// the first retry comes after the minimum backoff
+	waitFor(t, 5*standbyMinBackoff, "a retry", func() bool { return atomic.LoadInt32(&dials) >= 2 })
+	if session := standby.take(); session != nil {
+		t.Errorf("Expected no session while dialing fails")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		standby.close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Closing should stop the standby while it's backing off")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+// strictCipherList only selects AEAD cipher suites with forward secrecy.
+const strictCipherList = "ECDHE+AESGCM:ECDHE+CHACHA20:DHE+AESGCM:DHE+CHACHA20:!aNULL"
+
+// nonAEADFilter, appended to a cipher list, removes every AEAD cipher suite
+// so that whatever remains is a cipher that strict mode forbids.
+const nonAEADFilter = ":!AESGCM:!AESCCM:!CHACHA20"
+
+// validateStrictOptions returns an error naming the first option that is
+// incompatible with --sslStrict. Without a CA file the system certificate
+// authorities are trusted implicitly, so strict mode requires either a CA
+// file or asking for the system certificate authorities explicitly.
+func validateStrictOptions(opts options.ToolOptions) error {
+	switch {
+	case opts.SSLAllowInvalidCert:
+		return fmt.Errorf("--sslAllowInvalidCertificates is not allowed with --sslStrict")
+	case opts.SSLAllowInvalidHost:
+		return fmt.Errorf("--sslAllowInvalidHostnames is not allowed with --sslStrict")
+	case opts.SSLVerifyAuditOnly:
+		return fmt.Errorf("--sslVerifyAuditOnly is not allowed with --sslStrict")
+	case opts.SSLAllowNonCASigner:
+		return fmt.Errorf("--sslAllowNonCASigner is not allowed with --sslStrict")
+	case opts.SSLTrustOnFirstUse:
+		// the first connection to each server isn't verified at all
+		return fmt.Errorf("--sslTrustOnFirstUse is not allowed with --sslStrict")
+	case opts.SSLCAFile == "" && !opts.SSLUseSystemCA:
+		return fmt.Errorf("--sslStrict requires --sslCAFile or --sslUseSystemCA")
+	}
+	return nil
+}
+
+// validateInsecureAcknowledged returns an error if acknowledgment is required
+// for disabling verification and options disable it without acknowledging it.
+func validateInsecureAcknowledged(opts options.ToolOptions) error {
+	if !opts.RequireInsecureAcknowledgment || opts.SSLAcknowledgeInsecure {
+		return nil
+	}
+	switch {
+	case opts.SSLAllowInvalidCert:
+		return fmt.Errorf("--sslAllowInvalidCertificates requires acknowledging insecure connections")
+	case opts.SSLAllowInvalidHost:
+		return fmt.Errorf("--sslAllowInvalidHostnames requires acknowledging insecure connections")
+	case opts.SSLVerifyAuditOnly:
+		return fmt.Errorf("--sslVerifyAuditOnly requires acknowledging insecure connections")
+	case opts.SSLAllowNonCASigner:
+		// any certificate the CA issued could then sign a server's
+		return fmt.Errorf("--sslAllowNonCASigner requires acknowledging insecure connections")
+	case opts.SSLTrustOnFirstUse:
+		// the first connection to each server isn't verified at all
+		return fmt.Errorf("--sslTrustOnFirstUse requires acknowledging insecure connections")
+	}
+	return nil
+}
+
+// applyStrictCtx disables protocol versions below TLS 1.2 and compression on
+// the ctx, and returns the cipher list to use. A user-supplied cipher list is
+// rejected if it selects any non-AEAD cipher.
+func applyStrictCtx(ctx *openssl.Ctx, cipherList string) (string, error) {
+	ctx.SetOptions(openssl.NoSSLv3 | openssl.NoTLSv1 | openssl.NoTLSv1_1 | openssl.NoCompression)
+	if cipherList == "" {
+		return strictCipherList, nil
+	}
+	// setting the filtered list only succeeds if it still selects a cipher;
+	// the real list is set afterwards and replaces it
+	if err := ctx.SetCipherList(cipherList + nonAEADFilter); err == nil {
+		return "", fmt.Errorf("--sslCipherList '%v' selects non-AEAD ciphers, which are not allowed with --sslStrict", cipherList)
+	}
+	return cipherList, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestValidateStrictOptions(t *testing.T) {
+	cases := []struct {
+		Name  string
+		SSL   options.SSL
+		Error string
+	}{
+		{Name: "CA file", SSL: options.SSL{SSLCAFile: "ca.pem"}},
+		{Name: "system CAs", SSL: options.SSL{SSLUseSystemCA: true}},
+		{Name: "CA file and system CAs", SSL: options.SSL{SSLCAFile: "ca.pem", SSLUseSystemCA: true}},
+		{Name: "no CA file", SSL: options.SSL{}, Error: "requires --sslCAFile or --sslUseSystemCA"},
+		{Name: "invalid certificates", SSL: options.SSL{SSLCAFile: "ca.pem", SSLAllowInvalidCert: true}, Error: "--sslAllowInvalidCertificates"},
+		{Name: "invalid hostnames", SSL: options.SSL{SSLCAFile: "ca.pem", SSLAllowInvalidHost: true}, Error: "--sslAllowInvalidHostnames"},
+		{Name: "audit only", SSL: options.SSL{SSLCAFile: "ca.pem", SSLVerifyAuditOnly: true}, Error: "--sslVerifyAuditOnly"},
+		{Name: "non-CA signers", SSL: options.SSL{SSLCAFile: "ca.pem", SSLAllowNonCASigner: true}, Error: "--sslAllowNonCASigner"},
+		{Name: "trust on first use", SSL: options.SSL{SSLCAFile: "ca.pem", SSLTrustOnFirstUse: true}, Error: "--sslTrustOnFirstUse"},
+	}
+
+	for _, v := range cases {
+		opts := options.ToolOptions{SSL: &v.SSL}
+		err := validateStrictOptions(opts)
+		switch {
+		case v.Error == "" && err != nil:
+			t.Errorf("%v: unexpected error: %v", v.Name, err)
+		case v.Error != "" && err == nil:
+			t.Errorf("%v: expected an error but the options were accepted", v.Name)
+		case v.Error != "" && !strings.Contains(err.Error(), v.Error):
+			t.Errorf("%v: error should mention %q: %v", v.Name, v.Error, err)
+		}
+	}
+}
+
+func TestApplyStrictCtx(t *testing.T) {
+	cases := []struct {
+		CipherList string
+		Expected   string
+		Valid      bool
+	}{
+		{CipherList: "", Expected: strictCipherList, Valid: true},
+		{CipherList: "ECDHE+AESGCM", Expected: "ECDHE+AESGCM", Valid: true},
+		{CipherList: "AES128-SHA", Valid: false},
+		{CipherList: "ECDHE+AESGCM:AES128-SHA", Valid: false},
+	}
+
+	for _, v := range cases {
+		ctx, err := openssl.NewCtx()
+		if err != nil {
+			t.Fatalf("Error creating ctx: %v", err)
+		}
+		cipherList, err := applyStrictCtx(ctx, v.CipherList)
+		if !v.Valid {
+			if err == nil {
+				t.Errorf("Expected an error for cipher list %q but it was accepted", v.CipherList)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Error applying cipher list %q: %v", v.CipherList, err)
+		} else if cipherList != v.Expected {
+			t.Errorf("Cipher list %q became %q, expected %q", v.CipherList, cipherList, v.Expected)
+		}
+		if ctx.GetOptions()&openssl.NoTLSv1_1 == 0 {
+			t.Errorf("TLS 1.1 should be disabled")
+		}
+	}
+}
+
+func TestValidateInsecureAcknowledged(t *testing.T) {
+	insecure := []struct {
+		Name string
+		SSL  options.SSL
+	}{
+		{Name: "invalid certificates", SSL: options.SSL{SSLAllowInvalidCert: true}},
+		{Name: "invalid hostnames", SSL: options.SSL{SSLAllowInvalidHost: true}},
+		{Name: "audit only", SSL: options.SSL{SSLVerifyAuditOnly: true}},
+		{Name: "trust on first use", SSL: options.SSL{SSLTrustOnFirstUse: true}},
+		{Name: "non-CA signers", SSL: options.SSL{SSLAllowNonCASigner: true}},
+	}
+
+	for _, v := range insecure {
+		ssl := v.SSL
+		opts := options.ToolOptions{SSL: &ssl}
+		if err := validateInsecureAcknowledged(opts); err != nil {
+			t.Errorf("%v: unexpected error without requiring acknowledgment: %v", v.Name, err)
+		}
+		opts.RequireInsecureAcknowledgment = true
+		if err := validateInsecureAcknowledged(opts); err == nil {
+			t.Errorf("%v: expected an error without acknowledgment", v.Name)
+		}
+		opts.SSLAcknowledgeInsecure = true
+		if err := validateInsecureAcknowledged(opts); err != nil {
+			t.Errorf("%v: unexpected error with acknowledgment: %v", v.Name, err)
+		}
+	}
+
+	opts := options.ToolOptions{
+		SSL:                           &options.SSL{SSLCAFile: "ca.pem"},
+		RequireInsecureAcknowledgment: true,
+	}
+	if err := validateInsecureAcknowledged(opts); err != nil {
+		t.Errorf("Unexpected error for verified connections: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"sync"
+	"time"
+)
+
+// ConnectTimings breaks down how long establishing a session took. The DNS,
+// TCP and Handshake phases are those of the first connection mgo completed;
+// the others it makes while discovering the topology usually overlap it.
+type ConnectTimings struct {
+	// resolving the server's hostname
+	DNS time.Duration
+	// establishing the TCP connection
+	TCP time.Duration
+	// the ssl handshake, including hostname verification
+	Handshake time.Duration
+	// from the end of the first handshake until the session was ready, which
+	// covers authentication and mgo's initial server discovery
+	Auth time.Duration
+	// the whole call
+	Total time.Duration
+}
+
+// dialPhases holds the durations of a single connection attempt.
+type dialPhases struct {
+	dns, tcp, handshake time.Duration
+	done                time.Time
+
+	// the phase that failed, if the attempt failed
+	failed string
+	// the ssl handshake itself completed, even if a check of the server that
+	// follows it failed
+	handshakeOK bool
+}
+
+// timingRecorder fills in a ConnectTimings from the connections mgo makes
+// while establishing one session.
+type timingRecorder struct {
+	timings *ConnectTimings
+
+	mu        sync.Mutex
+	recorded  bool
+	firstDone time.Time
+}
+
+// recordDial records the phases of a successful connection if it is the
+// first one.
+func (r *timingRecorder) recordDial(phases dialPhases) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recorded {
+		return
+	}
+	r.recorded = true
+	r.timings.DNS = phases.dns
+	r.timings.TCP = phases.tcp
+	r.timings.Handshake = phases.handshake
+	r.firstDone = phases.done
+}
+
+// finish records the end of session setup at the given time.
+func (r *timingRecorder) finish(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recorded {
+		r.timings.Auth = at.Sub(r.firstDone)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestTimingRecorder(t *testing.T) {
+	var timings ConnectTimings
+	recorder := &timingRecorder{timings: &timings}
+	done := time.Now()
+
+	// nothing is recorded until a connection completes
+	recorder.finish(done)
+	if timings != (ConnectTimings{}) {
+		t.Errorf("Timings recorded without a connection: %+v", timings)
+	}
+
+	recorder.recordDial(dialPhases{dns: 1, tcp: 2, handshake: 3, done: done})
+	// only the first connection counts
+	recorder.recordDial(dialPhases{dns: 4, tcp: 5, handshake: 6, done: done.Add(time.Second)})
+	recorder.finish(done.Add(2 * time.Second))
+
+	expected := ConnectTimings{DNS: 1, TCP: 2, Handshake: 3, Auth: 2 * time.Second}
+	if timings != expected {
+		t.Errorf("Timings are %+v, expected %+v", timings, expected)
+	}
+}
+
+func TestGetNewSessionWithTimings(t *testing.T) {
+	dir, cleanup := testDir(t, "timings")
+	defer cleanup()
+
+	ca := newTestCA(t, "Timings Test CA")
+	address, closeServer := tlsServer(t, newServerCert(t, "server", ca))
+	defer closeServer()
+
+	connector := localConnector(t, address, pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.Timeout = 1
+	})
+	defer connector.Close()
+
+	// the server completes the handshake but doesn't speak the wire
+	// protocol, so the session fails after the connection's phases are timed
+	var timings ConnectTimings
+	session, err := connector.GetNewSessionWithTimings(&timings)
+	if err == nil {
+		session.Close()
+		t.Fatalf("Expected an error getting a session from a server that doesn't speak the wire protocol")
+	}
+	if timings.Handshake <= 0 || timings.TCP <= 0 {
+		t.Errorf("Connection phases should be timed even though the session failed: %+v", timings)
+	}
+	if timings.Total < timings.Handshake {
+		t.Errorf("Total %v should cover the handshake %v", timings.Total, timings.Handshake)
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// TLS record content types and handshake message types in the server's side
+// of the handshake.
+const (
+	recordChangeCipherSpec = 20
+	recordHandshake        = 22
+	handshakeServerHello   = 2
+	handshakeCertStatus    = 22
+)
+
+// TLS extension types the connector checks for.
+const (
+	extensionExtendedMasterSecret = 23
+	extensionSupportedVersions    = 43
+)
+
+// maxHandshakeRecording bounds how much of what the server sends is recorded
+// in case it never finishes its hello.
+const maxHandshakeRecording = 64 * 1024
+
+// helloRetryRandom is the random value of a server hello that is actually a
+// TLS 1.3 hello retry request.
+var helloRetryRandom = []byte{
+	0xCF, 0x21, 0xAD, 0x74, 0xE5, 0x9A, 0x61, 0x11, 0xBE, 0x1D, 0x8C, 0x02, 0x1E, 0x65, 0xB8, 0x91,
+	0xC2, 0xA2, 0x11, 0x16, 0x7A, 0xBB, 0x8C, 0x5E, 0x07, 0x9E, 0x09, 0xE2, 0xC8, 0xA8, 0x33, 0x9C,
+}
+
+// extensionNames are the registered names of the extensions a server may
+// send in its hello.
+var extensionNames = map[uint16]string{
+	0:     "server_name",
+	1:     "max_fragment_length",
+	5:     "status_request",
+	11:    "ec_point_formats",
+	15:    "heartbeat",
+	16:    "application_layer_protocol_negotiation",
+	18:    "signed_certificate_timestamp",
+	22:    "encrypt_then_mac",
+	23:    "extended_master_secret",
+	35:    "session_ticket",
+	41:    "pre_shared_key",
+	43:    "supported_versions",
+	44:    "cookie",
+	51:    "key_share",
+	65281: "renegotiation_info",
+}
+
+// TLSExtension is one extension the server sent in its hello.
+type TLSExtension struct {
+	ID uint16
+	// the registered name of the extension, or "unknown"
+	Name string
+}
+
+// NegotiatedExtensions describes the extensions the server agreed to in its
+// hello. A TLS 1.3 server encrypts every extension that isn't needed to
+// agree on keys, so for TLS 1.3 only supported_versions, key_share and
+// pre_shared_key are visible, and there's no equivalent to the extended
+// master secret extension because TLS 1.3 always binds keys to the handshake.
+type NegotiatedExtensions struct {
+	// the protocol version the server chose, such as "TLSv1.2"
+	Version    string
+	TLS13      bool
+	Extensions []TLSExtension
+	// the server stapled an OCSP response to its certificate; only visible
+	// before TLS 1.3
+	OCSPStapled bool
+}
+
+// Has reports whether the server sent the extension with the given ID.
+func (e NegotiatedExtensions) Has(id uint16) bool {
+	for _, extension := range e.Extensions {
+		if extension.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// helloRecorder records what the server sends until the handshake is parsed,
+// since the openssl bindings don't expose the negotiated extensions.
+type helloRecorder struct {
+	net.Conn
+
+	mu        sync.Mutex
+	recording bool
+	data      []byte
+
+	parsed     bool
+	negotiated NegotiatedExtensions
+	parseErr   error
+}
+
+func newHelloRecorder(conn net.Conn) *helloRecorder {
+	return &helloRecorder{Conn: conn, recording: true}
+}
+
+func (r *helloRecorder) Read(b []byte) (int, error) {
+	n, err := r.Conn.Read(b)
+	r.mu.Lock()
+	if r.recording {
+		r.data = append(r.data, b[:n]...)
+		if len(r.data) >= maxHandshakeRecording {
+			r.recording = false
+		}
+	}
+	r.mu.Unlock()
+	return n, err
+}
+
+// extensions stops recording and parses the server's hello from what was
+// recorded, the first time it's called. It must be called once the handshake
+// is complete.
+func (r *helloRecorder) extensions() (NegotiatedExtensions, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.parsed {
+		r.negotiated, r.parseErr = parseServerHandshake(r.data)
+		r.parsed = true
+		r.recording = false
+		r.data = nil
+	}
+	return r.negotiated, r.parseErr
+}
+
+// connExtensions holds the extensions negotiated on a connection, or why
+// they couldn't be determined. The zero value holds no connection.
+type connExtensions struct {
+	mu         sync.Mutex
+	seen       bool
+	extensions NegotiatedExtensions
+	err        error
+}
+
+func (e *connExtensions) set(extensions NegotiatedExtensions, err error) {
+	e.mu.Lock()
+	e.seen, e.extensions, e.err = true, extensions, err
+	e.mu.Unlock()
+}
+
+// ConnExtensions returns the TLS extensions the server negotiated on the most
+// recent successful connection to it, or an error if there hasn't been one or
+// the server's hello couldn't be parsed.
+func (self *SSLDBConnector) ConnExtensions() (NegotiatedExtensions, error) {
+	self.lastExtensions.mu.Lock()
+	defer self.lastExtensions.mu.Unlock()
+	if !self.lastExtensions.seen {
+		return NegotiatedExtensions{}, self.phaseError(phaseSession, fmt.Errorf("no connection to a server has been made"))
+	}
+	return self.lastExtensions.extensions, self.phaseError(failureHandshake, self.lastExtensions.err)
+}
+
+// tcpConn returns the TCP connection under conn, unwrapping a helloRecorder.
+func tcpConn(conn net.Conn) net.Conn {
+	if recorder, ok := conn.(*helloRecorder); ok {
+		return recorder.Conn
+	}
+	return conn
+}
+
+// parseServerHandshake finds the server's hello in the records the server
+// sent, and whether it stapled an OCSP response, stopping where the server
+// starts encrypting.
+func parseServerHandshake(data []byte) (NegotiatedExtensions, error) {
+	var result NegotiatedExtensions
+	var messages []byte
+	foundHello, retry := false, false
+records:
+	for len(data) >= 5 {
+		contentType := data[0]
+		length := int(data[3])<<8 | int(data[4])
+		if len(data) < 5+length {
+			break
+		}
+		fragment := data[5 : 5+length]
+		data = data[5+length:]
+
+		switch contentType {
+		case recordHandshake:
+		case recordChangeCipherSpec:
+			if retry {
+				// sent after a hello retry request for compatibility, and
+				// followed by the real hello
+				continue
+			}
+			break records
+		default:
+			break records
+		}
+		messages = append(messages, fragment...)
+		for len(messages) >= 4 {
+			msgType := messages[0]
+			msgLength := int(messages[1])<<16 | int(messages[2])<<8 | int(messages[3])
+			if len(messages) < 4+msgLength {
+				break
+			}
+			body := messages[4 : 4+msgLength]
+			messages = messages[4+msgLength:]
+			switch msgType {
+			case handshakeServerHello:
+				hello, isRetry, err := parseServerHello(body)
+				if err != nil {
+					return result, err
+				}
+				result.TLS13 = hello.TLS13
+				result.Version = hello.Version
+				result.Extensions = hello.Extensions
+				foundHello, retry = true, isRetry
+			case handshakeCertStatus:
+				result.OCSPStapled = true
+			}
+		}
+	}
+	if !foundHello {
+		return result, fmt.Errorf("no server hello found in the handshake")
+	}
+	return result, nil
+}
+
+// parseServerHello parses the body of a server hello, reporting whether it's
+// a TLS 1.3 hello retry request.
+func parseServerHello(body []byte) (NegotiatedExtensions, bool, error) {
+	var result NegotiatedExtensions
+	malformed := fmt.Errorf("malformed server hello")
+	// version and random
+	if len(body) < 35 {
+		return result, false, malformed
+	}
+	// superseded by the supported_versions extension from TLS 1.3
+	version := uint16(body[0])<<8 | uint16(body[1])
+	retry := bytes.Equal(body[2:34], helloRetryRandom)
+	sessionIDLength := int(body[34])
+	// session ID, cipher suite and compression method
+	rest := body[35:]
+	if len(rest) < sessionIDLength+3 {
+		return result, false, malformed
+	}
+	rest = rest[sessionIDLength+3:]
+	if len(rest) == 0 {
+		result.Version = protocolName(version)
+		return result, retry, nil
+	}
+	if len(rest) < 2 {
+		return result, false, malformed
+	}
+	extensionsLength := int(rest[0])<<8 | int(rest[1])
+	rest = rest[2:]
+	if len(rest) < extensionsLength {
+		return result, false, malformed
+	}
+	rest = rest[:extensionsLength]
+	for len(rest) > 0 {
+		if len(rest) < 4 {
+			return result, false, malformed
+		}
+		id := uint16(rest[0])<<8 | uint16(rest[1])
+		length := int(rest[2])<<8 | int(rest[3])
+		if len(rest) < 4+length {
+			return result, false, malformed
+		}
+		value := rest[4 : 4+length]
+		rest = rest[4+length:]
+
+		name, ok := extensionNames[id]
+		if !ok {
+			name = "unknown"
+		}
+		result.Extensions = append(result.Extensions, TLSExtension{ID: id, Name: name})
+		if id == extensionSupportedVersions && len(value) == 2 {
+			version = uint16(value[0])<<8 | uint16(value[1])
+		}
+	}
+	result.TLS13 = version == 0x0304
+	result.Version = protocolName(version)
+	return result, retry, nil
+}
+
+// protocolNames names protocol versions the way OpenSSL does.
+var protocolNames = map[uint16]string{
+	0x0300: "SSLv3",
+	0x0301: "TLSv1",
+	0x0302: "TLSv1.1",
+	0x0303: "TLSv1.2",
+	0x0304: "TLSv1.3",
+}
+
+// protocolName returns the name of the protocol version, or its number if
+// it's not one OpenSSL knows.
+func protocolName(version uint16) string {
+	if name, ok := protocolNames[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
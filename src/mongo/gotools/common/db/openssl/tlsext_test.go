@@ -0,0 +1,94 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestConnExtensions(t *testing.T) {
+	dir, cleanup := testDir(t, "tlsext")
+	defer cleanup()
+
+	ca := newTestCA(t, "Extensions Test CA")
+	server := newServerCert(t, "server", ca)
+	caFile := pemFile(t, dir, "ca.pem", ca)
+
+	cases := []struct {
+		Name    string
+		Version uint16
+		Want    string
+		TLS13   bool
+		Sent    []uint16
+	}{
+		{Name: "TLS 1.2", Version: tls.VersionTLS12, Want: "TLSv1.2",
+			Sent: []uint16{extensionExtendedMasterSecret, 65281}},
+		{Name: "TLS 1.3", Version: tls.VersionTLS13, Want: "TLSv1.3", TLS13: true,
+			Sent: []uint16{extensionSupportedVersions, 51}},
+	}
+
+	for _, v := range cases {
+		address, closeServer := configuredTLSServer(t, &tls.Config{MinVersion: v.Version, MaxVersion: v.Version}, 0, server)
+		connector := localConnector(t, address, caFile, nil)
+		if _, err := connector.ConnExtensions(); err == nil {
+			t.Errorf("%v: expected an error before any connection was made", v.Name)
+		}
+		if conn, err := connector.dial(address); err != nil {
+			t.Errorf("%v: error dialing: %v", v.Name, err)
+		} else {
+			conn.Close()
+		}
+
+		negotiated, err := connector.ConnExtensions()
+		switch {
+		case err != nil:
+			t.Errorf("%v: unexpected error: %v", v.Name, err)
+		case negotiated.Version != v.Want || negotiated.TLS13 != v.TLS13:
+			t.Errorf("%v: negotiated %v (TLS 1.3 %v), expected %v", v.Name, negotiated.Version, negotiated.TLS13, v.Want)
+		case negotiated.OCSPStapled:
+			t.Errorf("%v: no OCSP response was stapled", v.Name)
+		}
+		for _, id := range v.Sent {
+			if !negotiated.Has(id) {
+				t.Errorf("%v: expected the server to send %v: %+v", v.Name, extensionNames[id], negotiated.Extensions)
+			}
+		}
+		for _, extension := range negotiated.Extensions {
+			if extension.Name != extensionNames[extension.ID] {
+				t.Errorf("%v: extension %v is named %q", v.Name, extension.ID, extension.Name)
+			}
+		}
+		connector.Close()
+		closeServer()
+	}
+}
+
+func TestParseServerHandshakeMalformed(t *testing.T) {
+	hello := func(body ...byte) []byte {
+		message := append([]byte{handshakeServerHello, 0, 0, byte(len(body))}, body...)
+		return append([]byte{recordHandshake, 3, 3, 0, byte(len(message))}, message...)
+	}
+
+	cases := []struct {
+		Name string
+		Data []byte
+	}{
+		{Name: "nothing", Data: nil},
+		{Name: "truncated record", Data: []byte{recordHandshake, 3, 3, 0, 40, handshakeServerHello}},
+		{Name: "alert", Data: []byte{21, 3, 3, 0, 2, 2, 40}},
+		{Name: "short hello", Data: hello(3, 3, 0)},
+	}
+
+	for _, v := range cases {
+		if _, err := parseServerHandshake(v.Data); err == nil {
+			t.Errorf("%v: expected an error", v.Name)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gopkg.in/mgo.v2"
+)
+
+// Node roles reported by Topology.
+const (
+	RolePrimary    = "primary"
+	RoleSecondary  = "secondary"
+	RoleArbiter    = "arbiter"
+	RoleMongos     = "mongos"
+	RoleStandalone = "standalone"
+	// a member that is starting up, recovering or otherwise not serving
+	RoleOther = "other"
+)
+
+// NodeInfo describes one node of the deployment a session is connected to.
+type NodeInfo struct {
+	Address string
+	// one of the Role constants, or empty if the node couldn't be queried
+	Role string
+	// mgo considers the node live and may send operations to it; arbiters
+	// and members a session is not allowed to use are never live
+	Live bool
+	// the node answered isMaster as a primary, secondary, arbiter, mongos or
+	// standalone
+	Healthy bool
+
+	Err error
+}
+
+// isMasterResult holds the fields of isMaster that describe a node's role
+// and the membership of its replica set.
+type isMasterResult struct {
+	IsMaster    bool     `bson:"ismaster"`
+	Secondary   bool     `bson:"secondary"`
+	ArbiterOnly bool     `bson:"arbiterOnly"`
+	SetName     string   `bson:"setName"`
+	Msg         string   `bson:"msg"`
+	Hosts       []string `bson:"hosts"`
+	Passives    []string `bson:"passives"`
+	Arbiters    []string `bson:"arbiters"`
+}
+
+// role returns the Role constant for the node that returned result.
+func (result isMasterResult) role() string {
+	switch {
+	case result.Msg == "isdbgrid":
+		return RoleMongos
+	case result.ArbiterOnly:
+		return RoleArbiter
+	case result.IsMaster && result.SetName != "":
+		return RolePrimary
+	case result.IsMaster:
+		return RoleStandalone
+	case result.Secondary:
+		return RoleSecondary
+	}
+	return RoleOther
+}
+
+// Topology connects a new session and reports every node it knows about,
+// sorted by address: the servers mgo considers live, plus every member the
+// replica set lists, including arbiters. Each node is queried directly for
+// its role and health. A session pinned to a member or connected to the
+// fastest node only considers that node live.
+func (self *SSLDBConnector) Topology() ([]NodeInfo, error) {
+	session, err := self.newSession(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	live := map[string]bool{}
+	for _, addr := range session.LiveServers() {
+		live[addr] = true
+	}
+	// any member can list the set, and in strong mode isMaster would fail
+	// on a direct connection to a secondary or while there's no primary
+	session.SetMode(mgo.Monotonic, true)
+	var result isMasterResult
+	if err = session.Run("isMaster", &result); err != nil {
+		return nil, self.phaseError(phaseCommand, fmt.Errorf("error running isMaster: %v", err))
+	}
+	addrs := map[string]bool{}
+	for addr := range live {
+		addrs[addr] = true
+	}
+	for _, members := range [][]string{result.Hosts, result.Passives, result.Arbiters} {
+		for _, addr := range members {
+			addrs[addr] = true
+		}
+	}
+
+	nodes := make([]NodeInfo, 0, len(addrs))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for addr := range addrs {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			node := self.probeNode(addr)
+			node.Live = live[addr]
+			mutex.Lock()
+			nodes = append(nodes, node)
+			mutex.Unlock()
+		}(addr)
+	}
+	wg.Wait()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Address < nodes[j].Address })
+	return nodes, nil
+}
+
+// probeNode connects directly to addr and asks it for its role. Arbiters have
+// no users, and isMaster needs no authentication, so the session doesn't
+// authenticate. It's in eventual mode, since in strong mode a direct session
+// can only run commands on a primary.
+func (self *SSLDBConnector) probeNode(addr string) NodeInfo {
+	node := NodeInfo{Address: addr}
+	info := *self.dialInfo
+	info.Addrs = []string{addr}
+	info.Direct = true
+	info.Username = ""
+	info.Password = ""
+	info.Mechanism = ""
+	info.Source = ""
+
+	session, err := mgo.DialWithInfo(&info)
+	if err != nil {
+		node.Err = self.phaseError(phaseSession, err)
+		return node
+	}
+	defer session.Close()
+	session.SetMode(mgo.Eventual, true)
+	var result isMasterResult
+	if err = session.Run("isMaster", &result); err != nil {
+		node.Err = self.phaseError(phaseCommand, fmt.Errorf("error running isMaster: %v", err))
+		return node
+	}
+	node.Role = result.role()
+	node.Healthy = node.Role != RoleOther
+	return node
+}
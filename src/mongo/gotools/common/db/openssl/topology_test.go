@@ -0,0 +1,34 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"testing"
+)
+
+func TestIsMasterRole(t *testing.T) {
+	cases := []struct {
+		Result isMasterResult
+		Role   string
+	}{
+		{Result: isMasterResult{IsMaster: true, SetName: "rs0"}, Role: RolePrimary},
+		{Result: isMasterResult{Secondary: true, SetName: "rs0"}, Role: RoleSecondary},
+		{Result: isMasterResult{ArbiterOnly: true, SetName: "rs0"}, Role: RoleArbiter},
+		{Result: isMasterResult{IsMaster: true, Msg: "isdbgrid"}, Role: RoleMongos},
+		{Result: isMasterResult{IsMaster: true}, Role: RoleStandalone},
+		// a member that's recovering or still starting up
+		{Result: isMasterResult{SetName: "rs0"}, Role: RoleOther},
+	}
+
+	for _, v := range cases {
+		if role := v.Result.role(); role != v.Role {
+			t.Errorf("Role of %+v is %v, expected %v", v.Result, role, v.Role)
+		}
+	}
+}
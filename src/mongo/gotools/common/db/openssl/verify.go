@@ -0,0 +1,88 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"sync"
+)
+
+// HostResult describes how far a connection to a single seed host got. Each
+// stage is only attempted if the previous one succeeded, and Err holds the
+// error of the first stage that failed.
+type HostResult struct {
+	// the TCP connection was established
+	Reachable bool
+	// the ssl handshake completed
+	HandshakeOK bool
+	// the server certificate passed verification, including the hostname
+	// check unless invalid hostnames are allowed, along with every other
+	// check of the server the connector is configured with
+	CertOK bool
+	// an authenticated session to the host answered a ping
+	PingOK bool
+
+	Err error
+}
+
+// VerifyAllHosts connects to each seed host individually and reports which
+// stages of connecting succeeded for each of them, keyed by host address.
+// Each host gets the health check timeout. It must be called after Configure.
+func (self *SSLDBConnector) VerifyAllHosts() map[string]HostResult {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	addrs := self.currentDialInfo().Addrs
+	results := make(map[string]HostResult, len(addrs))
+	for _, address := range addrs {
+		wg.Add(1)
+		go func(address string) {
+			defer wg.Done()
+			result := self.verifyHost(address)
+			mutex.Lock()
+			results[address] = result
+			mutex.Unlock()
+		}(address)
+	}
+	wg.Wait()
+	return results
+}
+
+// verifyHost runs through the stages of connecting to a single host. The
+// connection is made like any other, so it's subject to every check the
+// connector is configured with.
+func (self *SSLDBConnector) verifyHost(address string) (result HostResult) {
+	timeout := self.healthCheckTimeout()
+	conn, phases, err := self.connect(address, timeout)
+	if err != nil {
+		switch phases.failed {
+		case failureAddress, failureDNS, failureTCP:
+		default:
+			result.Reachable = true
+			result.HandshakeOK = phases.handshakeOK
+		}
+		result.Err = self.phaseError(phases.failed, err)
+		return
+	}
+	conn.Close()
+	result.Reachable = true
+	result.HandshakeOK = true
+	result.CertOK = true
+
+	// use a direct session so that only this host is contacted; it's
+	// authenticated and pinged before it's closed again
+	dialInfo := *self.dialInfo
+	dialInfo.Addrs = []string{address}
+	dialInfo.Direct = true
+	dialInfo.ReplicaSetName = ""
+	if err = dialWithin(dialInfo, timeout); err != nil {
+		result.Err = self.phaseError(phaseSession, err)
+		return
+	}
+	result.PingOK = true
+	return
+}
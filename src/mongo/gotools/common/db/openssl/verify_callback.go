@@ -0,0 +1,241 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/log"
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+// verifyCallback returns the callback OpenSSL calls for each certificate in
+// the server's chain, or nil if the default verification result should be
+// used unchanged.
+func verifyCallback(opts options.ToolOptions) (openssl.VerifyCallback, error) {
+	if !opts.SSLAllowNonCASigner && !opts.SSLIgnoreRootInChain {
+		return nil, nil
+	}
+	var roots []*x509.Certificate
+	if opts.SSLIgnoreRootInChain {
+		var err error
+		if roots, err = trustedRoots(opts.SSLCAFile); err != nil {
+			return nil, err
+		}
+	}
+	return func(ok bool, store *openssl.CertificateStoreCtx) bool {
+		if ok {
+			return true
+		}
+		// only the CA flag of the basic constraints is relaxed; every
+		// other failure is still fatal
+		if opts.SSLAllowNonCASigner && isNonCASignerError(store) {
+			// log once per certificate rather than once per error
+			if store.VerifyResult() == openssl.InvalidCa {
+				log.Logvf(log.Always, "WARNING: accepting certificate %v that is not marked as a CA "+
+					"at depth %v of the server's chain", currentSubject(store), store.Depth())
+			}
+			return true
+		}
+		if opts.SSLIgnoreRootInChain && isTrustedRootInChain(store, roots) {
+			log.Logvf(log.DebugLow, "ignoring self-signed certificate %v in the server's chain, "+
+				"which matches a locally trusted root", currentSubject(store))
+			return true
+		}
+		return false
+	}, nil
+}
+
+// trustedRoots loads the self-signed certificates in caFile, which a root in
+// the server's chain is compared against.
+func trustedRoots(caFile string) ([]*x509.Certificate, error) {
+	if caFile == "" {
+		return nil, fmt.Errorf("comparing the server's chain with trusted roots requires a CA file")
+	}
+	pemBytes, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA file: %v", err)
+	}
+	var roots []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate in CA file: %v", err)
+		}
+		if isSelfSigned(cert) {
+			roots = append(roots, cert)
+		}
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no root certificates found in CA file %v", caFile)
+	}
+	return roots, nil
+}
+
+// isSelfSigned reports whether cert is issued by and signed with its own key.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// isTrustedRootInChain reports whether verification failed only because the
+// server included a self-signed root in its chain with the same name and key
+// as a root we trust locally, which OpenSSL rejects if the copies differ in
+// any other way, for example after the root was re-issued.
+func isTrustedRootInChain(store *openssl.CertificateStoreCtx, roots []*x509.Certificate) bool {
+	if store.VerifyResult() != openssl.SelfSignedCertInChain {
+		return false
+	}
+	cert := store.GetCurrentCert()
+	if cert == nil {
+		return false
+	}
+	parsed, err := toX509(cert)
+	if err != nil {
+		return false
+	}
+	return matchesTrustedRoot(parsed, roots)
+}
+
+// matchesTrustedRoot reports whether cert is self-signed with the same name
+// and key as one of roots.
+func matchesTrustedRoot(cert *x509.Certificate, roots []*x509.Certificate) bool {
+	if !isSelfSigned(cert) {
+		return false
+	}
+	for _, root := range roots {
+		if bytes.Equal(cert.RawSubject, root.RawSubject) &&
+			bytes.Equal(cert.RawSubjectPublicKeyInfo, root.RawSubjectPublicKeyInfo) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNonCASignerError reports whether verification failed only because an
+// issuing certificate lacks the CA flag. OpenSSL reports this as an invalid
+// CA and again as an invalid purpose, since the CA purpose check also looks
+// at the basic constraints.
+func isNonCASignerError(store *openssl.CertificateStoreCtx) bool {
+	cert := store.GetCurrentCert()
+	if cert == nil {
+		return false
+	}
+	parsed, err := toX509(cert)
+	if err != nil {
+		return false
+	}
+	return nonCASignerFailure(store.VerifyResult(), store.Depth(), parsed)
+}
+
+// nonCASignerFailure reports whether result, reported for cert at depth in
+// the chain, is caused only by cert lacking the CA flag. OpenSSL also reports
+// an invalid CA for issuers that are marked as CAs but whose key usage
+// doesn't allow signing certificates, which must remain fatal, so the
+// certificate itself is checked rather than trusting the result alone.
+func nonCASignerFailure(result openssl.VerifyResult, depth int, cert *x509.Certificate) bool {
+	if result != openssl.InvalidCa && result != openssl.InvalidPurpose {
+		return false
+	}
+	if depth == 0 || cert.IsCA {
+		return false
+	}
+	// a key usage that doesn't allow signing certificates is a failure of
+	// its own, which OpenSSL reports as the same error
+	return cert.KeyUsage == 0 || cert.KeyUsage&x509.KeyUsageCertSign != 0
+}
+
+// checkNonCASignerRoot returns an error if an issuing certificate in the
+// chain presented on conn isn't marked as a CA, and so was only accepted
+// under --sslAllowNonCASigner, but the chain doesn't lead up to one of roots
+// from the CA file. A chain that ends at a system root, which the user
+// didn't choose to trust this far, must meet the CA requirements in full.
+// The chain is followed through the certificates the server sent and then
+// through cas, which may hold intermediates from the CA file.
+func checkNonCASignerRoot(conn *openssl.Conn, cas, roots []*x509.Certificate) error {
+	chain, err := conn.PeerCertificateChain()
+	if err != nil {
+		return fmt.Errorf("error getting the server's certificate chain: %v", err)
+	}
+	parsed := make([]*x509.Certificate, 0, len(chain))
+	relaxed := false
+	for i, cert := range chain {
+		x509Cert, err := toX509(cert)
+		if err != nil {
+			return fmt.Errorf("error parsing the server's certificate chain: %v", err)
+		}
+		if i > 0 && !x509Cert.IsCA {
+			relaxed = true
+		}
+		parsed = append(parsed, x509Cert)
+	}
+	if !relaxed || len(parsed) == 0 {
+		return nil
+	}
+
+	candidates := append(parsed[1:len(parsed):len(parsed)], cas...)
+	cert := parsed[0]
+	// each certificate can appear in a path at most once
+	for i := 0; i <= len(candidates); i++ {
+		if isSelfSigned(cert) {
+			if matchesTrustedRoot(cert, roots) {
+				return nil
+			}
+			break
+		}
+		issuer := findIssuer(cert, candidates)
+		if issuer == nil {
+			break
+		}
+		cert = issuer
+	}
+	return fmt.Errorf("server's chain relies on an issuer that is not marked as a CA, " +
+		"which --sslAllowNonCASigner only accepts for chains ending at a root in --sslCAFile")
+}
+
+// findIssuer returns the first of candidates that issued and signed cert, or
+// nil if none did. The signature is checked directly since the issuer may not
+// be marked as a CA.
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if !bytes.Equal(candidate.RawSubject, cert.RawIssuer) {
+			continue
+		}
+		if candidate.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// currentSubject returns the subject of the certificate being verified, for
+// use in log messages.
+func currentSubject(store *openssl.CertificateStoreCtx) string {
+	cert := store.GetCurrentCert()
+	if cert == nil {
+		return "<unknown>"
+	}
+	parsed, err := toX509(cert)
+	if err != nil {
+		return "<unknown>"
+	}
+	return "'" + parsed.Subject.String() + "'"
+}
@@ -0,0 +1,167 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/openssl"
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestNonCASignerFailure(t *testing.T) {
+	nonCA := &x509.Certificate{KeyUsage: x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign}
+	nonCANoKeyUsage := &x509.Certificate{}
+	nonCANoCertSign := &x509.Certificate{KeyUsage: x509.KeyUsageDigitalSignature}
+	ca := &x509.Certificate{IsCA: true, KeyUsage: x509.KeyUsageDigitalSignature}
+
+	cases := []struct {
+		Name    string
+		Result  openssl.VerifyResult
+		Depth   int
+		Cert    *x509.Certificate
+		Relaxed bool
+	}{
+		{Name: "issuer without the CA flag", Result: openssl.InvalidCa, Depth: 1, Cert: nonCA, Relaxed: true},
+		{Name: "issuer without the CA flag or key usage", Result: openssl.InvalidCa, Depth: 1, Cert: nonCANoKeyUsage, Relaxed: true},
+		{Name: "purpose check of an issuer without the CA flag", Result: openssl.InvalidPurpose, Depth: 2, Cert: nonCA, Relaxed: true},
+		{Name: "CA that can't sign certificates", Result: openssl.InvalidCa, Depth: 1, Cert: ca},
+		{Name: "issuer whose key usage doesn't allow signing certificates", Result: openssl.InvalidCa, Depth: 1, Cert: nonCANoCertSign},
+		{Name: "leaf with the wrong purpose", Result: openssl.InvalidPurpose, Depth: 0, Cert: nonCA},
+		{Name: "expired issuer", Result: openssl.CertHasExpired, Depth: 1, Cert: nonCA},
+		{Name: "untrusted issuer", Result: openssl.UnableToGetIssuerCert, Depth: 1, Cert: nonCA},
+	}
+
+	for _, v := range cases {
+		if relaxed := nonCASignerFailure(v.Result, v.Depth, v.Cert); relaxed != v.Relaxed {
+			t.Errorf("%v: relaxed is %v, expected %v", v.Name, relaxed, v.Relaxed)
+		}
+	}
+}
+
+func TestTrustedRoots(t *testing.T) {
+	dir, cleanup := testDir(t, "roots")
+	defer cleanup()
+
+	root := newTestCA(t, "Root CA")
+	intermediate := newTestIntermediate(t, "Intermediate CA", root)
+
+	cases := []struct {
+		Name   string
+		CAFile string
+		Roots  int
+	}{
+		{Name: "roots and intermediates", CAFile: pemFile(t, dir, "both.pem", intermediate, root), Roots: 1},
+		{Name: "only intermediates", CAFile: pemFile(t, dir, "intermediates.pem", intermediate)},
+		{Name: "no CA file", CAFile: ""},
+		{Name: "missing CA file", CAFile: filepath.Join(dir, "missing.pem")},
+	}
+
+	for _, v := range cases {
+		roots, err := trustedRoots(v.CAFile)
+		if v.Roots == 0 {
+			if err == nil {
+				t.Errorf("%v: expected an error", v.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", v.Name, err)
+		} else if len(roots) != v.Roots {
+			t.Errorf("%v: found %v roots, expected %v", v.Name, len(roots), v.Roots)
+		}
+	}
+}
+
+func TestMatchesTrustedRoot(t *testing.T) {
+	root := newTestCA(t, "Root CA")
+	// the same root re-issued with the same name and key, which OpenSSL
+	// doesn't treat as the same certificate
+	template := *root.cert
+	template.SerialNumber = big.NewInt(2)
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &root.key.PublicKey, root.key)
+	if err != nil {
+		t.Fatalf("Error re-issuing root: %v", err)
+	}
+	reissued, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error parsing re-issued root: %v", err)
+	}
+	sameName := newTestCA(t, "Root CA")
+	issued := newTestCert(t, "Root CA", x509.Certificate{}, root)
+
+	cases := []struct {
+		Name    string
+		Cert    *x509.Certificate
+		Matches bool
+	}{
+		{Name: "the trusted root", Cert: root.cert, Matches: true},
+		{Name: "re-issued root", Cert: reissued, Matches: true},
+		{Name: "same name with another key", Cert: sameName.cert},
+		{Name: "not self-signed", Cert: issued.cert},
+	}
+
+	for _, v := range cases {
+		if matches := matchesTrustedRoot(v.Cert, []*x509.Certificate{root.cert}); matches != v.Matches {
+			t.Errorf("%v: matches is %v, expected %v", v.Name, matches, v.Matches)
+		}
+	}
+}
+
+func TestAllowNonCASignerRoots(t *testing.T) {
+	dir, cleanup := testDir(t, "noncasigner")
+	defer cleanup()
+
+	root := newTestCA(t, "Non-CA Signer Root CA")
+	// an issuer that can sign certificates but isn't marked as a CA
+	signer := newTestCert(t, "Non-CA Signer", x509.Certificate{
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, root)
+	address, closeServer := tlsServer(t, newServerCert(t, "server", signer), signer)
+	defer closeServer()
+
+	// OpenSSL reads the default verify paths from the environment each time
+	// they're loaded, so the test root stands in for the system store
+	defer os.Setenv("SSL_CERT_FILE", os.Getenv("SSL_CERT_FILE"))
+	os.Setenv("SSL_CERT_FILE", pemFile(t, dir, "system.pem", root))
+
+	cases := []struct {
+		Name   string
+		CAFile string
+		With   func(opts *options.ToolOptions)
+		Valid  bool
+	}{
+		{Name: "root in the CA file", CAFile: pemFile(t, dir, "ca.pem", root), Valid: true,
+			With: func(opts *options.ToolOptions) { opts.SSLAllowNonCASigner = true }},
+		{Name: "root in the CA file without the relaxation", CAFile: pemFile(t, dir, "ca.pem", root)},
+		{Name: "system root", CAFile: pemFile(t, dir, "other.pem", newTestCA(t, "Other Root CA")),
+			With: func(opts *options.ToolOptions) {
+				opts.SSLAllowNonCASigner = true
+				opts.SSLUseSystemCA = true
+			}},
+	}
+
+	for _, v := range cases {
+		connector := localConnector(t, address, v.CAFile, v.With)
+		conn, err := connector.dial(address)
+		if err == nil {
+			conn.Close()
+		}
+		connector.Close()
+		if (err == nil) != v.Valid {
+			t.Errorf("%v: valid is %v, expected %v: %v", v.Name, err == nil, v.Valid, err)
+		}
+	}
+}
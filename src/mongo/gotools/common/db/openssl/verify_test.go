@@ -0,0 +1,121 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// +build ssl,!openssl_pre_1.0
+
+package openssl
+
+import (
+	"crypto/x509"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestVerifyAllHosts(t *testing.T) {
+	dir, cleanup := testDir(t, "verify")
+	defer cleanup()
+
+	ca := newTestCA(t, "Verify Test CA")
+	untrusted := newTestCA(t, "Untrusted CA")
+
+	good, closeGood := tlsServer(t, newServerCert(t, "good", ca))
+	defer closeGood()
+	wrongHost, closeWrongHost := tlsServer(t, newTestCert(t, "wrong host", x509.Certificate{DNSNames: []string{"elsewhere"}}, ca))
+	defer closeWrongHost()
+	wrongCA, closeWrongCA := tlsServer(t, newServerCert(t, "wrong CA", untrusted))
+	defer closeWrongCA()
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	defer silent.Close()
+	unreachable := closedAddr(t)
+
+	cases := []struct {
+		Name    string
+		Address string
+		Result  HostResult
+		Error   string
+	}{
+		// nothing speaks the wire protocol, so no host gets as far as a ping
+		{Name: "good", Address: good, Result: HostResult{Reachable: true, HandshakeOK: true, CertOK: true}},
+		{Name: "wrong host", Address: wrongHost, Result: HostResult{Reachable: true, HandshakeOK: true}},
+		{Name: "wrong CA", Address: wrongCA, Result: HostResult{Reachable: true}},
+		{Name: "silent", Address: silent.Addr().String(), Result: HostResult{Reachable: true}, Error: "timeout"},
+		{Name: "unreachable", Address: unreachable, Result: HostResult{}},
+	}
+	addrs := make([]string, len(cases))
+	for i, v := range cases {
+		addrs[i] = v.Address
+	}
+
+	connector := localConnector(t, strings.Join(addrs, ","), pemFile(t, dir, "ca.pem", ca), func(opts *options.ToolOptions) {
+		opts.HealthCheckSelectionTimeout = 500 * time.Millisecond
+	})
+	defer connector.Close()
+	results := connector.VerifyAllHosts()
+
+	for _, v := range cases {
+		result, ok := results[v.Address]
+		if !ok {
+			t.Errorf("%v: no result for %v", v.Name, v.Address)
+			continue
+		}
+		if result.Err == nil {
+			t.Errorf("%v: expected an error", v.Name)
+		} else if v.Error != "" && !strings.Contains(result.Err.Error(), v.Error) {
+			t.Errorf("%v: error should mention %q: %v", v.Name, v.Error, result.Err)
+		}
+		result.Err = nil
+		if result != v.Result {
+			t.Errorf("%v: result is %+v, expected %+v", v.Name, result, v.Result)
+		}
+	}
+}
+
+func TestVerifySystemRootsWithIntermediates(t *testing.T) {
+	dir, cleanup := testDir(t, "verify")
+	defer cleanup()
+
+	root := newTestCA(t, "System Root CA")
+	intermediate := newTestIntermediate(t, "Intermediate CA", root)
+	// the server presents only its own certificate, so the chain can only be
+	// built with the intermediate from the CA file
+	server, closeServer := tlsServer(t, newServerCert(t, "server", intermediate))
+	defer closeServer()
+
+	// OpenSSL reads the default verify paths from the environment each time
+	// they're loaded, so the test root stands in for the system store
+	defer os.Setenv("SSL_CERT_FILE", os.Getenv("SSL_CERT_FILE"))
+	os.Setenv("SSL_CERT_FILE", pemFile(t, dir, "system.pem", root))
+	intermediates := pemFile(t, dir, "intermediates.pem", intermediate)
+
+	cases := []struct {
+		Name     string
+		SystemCA bool
+		Verified bool
+	}{
+		{Name: "intermediates and system roots", SystemCA: true, Verified: true},
+		{Name: "intermediates only", SystemCA: false},
+	}
+
+	for _, v := range cases {
+		connector := localConnector(t, server, intermediates, func(opts *options.ToolOptions) {
+			opts.SSLUseSystemCA = v.SystemCA
+			opts.HealthCheckSelectionTimeout = 500 * time.Millisecond
+		})
+		result := connector.VerifyAllHosts()[server]
+		connector.Close()
+		if result.CertOK != v.Verified {
+			t.Errorf("%v: certificate verified is %v, expected %v: %v", v.Name, result.CertOK, v.Verified, result.Err)
+		}
+	}
+}
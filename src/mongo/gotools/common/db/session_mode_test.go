@@ -0,0 +1,149 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/options"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// secondaryServer answers every OP_QUERY command as a replica set secondary
+// would: isMaster with its state, and anything else with ok. It returns the
+// address it listens on.
+func secondaryServer(t *testing.T) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSecondary(conn)
+		}
+	}()
+	return listener.Addr().String(), func() { listener.Close() }
+}
+
+func serveSecondary(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var header [16]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		length := int(binary.LittleEndian.Uint32(header[0:]))
+		requestID := binary.LittleEndian.Uint32(header[4:])
+		body := make([]byte, length-len(header))
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		// flags, then the collection name, skip and limit before the query
+		name := bytes.IndexByte(body[4:], 0)
+		if name < 0 {
+			return
+		}
+		var query bson.D
+		if err := bson.Unmarshal(body[4+name+1+8:], &query); err != nil || len(query) == 0 {
+			return
+		}
+		if query[0].Name == "$query" {
+			if wrapped, ok := query[0].Value.(bson.D); ok && len(wrapped) > 0 {
+				query = wrapped
+			}
+		}
+
+		doc := bson.M{"ok": 1}
+		if query[0].Name == "ismaster" || query[0].Name == "isMaster" {
+			doc = bson.M{"ismaster": false, "secondary": true, "setName": "rs0", "maxWireVersion": 2, "ok": 1}
+		}
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return
+		}
+		message := make([]byte, 36, 36+len(data))
+		binary.LittleEndian.PutUint32(message[0:], uint32(36+len(data)))
+		binary.LittleEndian.PutUint32(message[8:], requestID)
+		binary.LittleEndian.PutUint32(message[12:], 1)
+		// a single document, with no flags or cursor
+		binary.LittleEndian.PutUint32(message[32:], 1)
+		if _, err = conn.Write(append(message, data...)); err != nil {
+			return
+		}
+	}
+}
+
+// directConnector connects each session directly to addr, in monotonic mode,
+// and reports whether its sessions are direct as direct.
+type directConnector struct {
+	addr   string
+	direct bool
+}
+
+func (c *directConnector) Configure(options.ToolOptions) error {
+	return nil
+}
+
+func (c *directConnector) GetNewSession() (*mgo.Session, error) {
+	session, err := mgo.DialWithInfo(&mgo.DialInfo{Addrs: []string{c.addr}, Direct: true, Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	session.SetMode(mgo.Monotonic, true)
+	return session, nil
+}
+
+func (c *directConnector) DirectSessions() bool {
+	return c.direct
+}
+
+func TestSessionProviderDirectSessions(t *testing.T) {
+	Convey("With a connector whose sessions are connected directly to a secondary", t, func() {
+		addr, closeServer := secondaryServer(t)
+		defer closeServer()
+
+		Convey("the default primary read preference should allow reading from it", func() {
+			provider := &SessionProvider{connector: &directConnector{addr: addr, direct: true}, readPreference: mgo.Primary}
+			defer provider.Close()
+			session, err := provider.GetSession()
+			So(err, ShouldBeNil)
+			defer session.Close()
+			So(session.Mode(), ShouldEqual, mgo.Monotonic)
+			So(session.Run("ping", &bson.M{}), ShouldBeNil)
+		})
+
+		Convey("a read preference that allows secondaries should be kept", func() {
+			provider := &SessionProvider{connector: &directConnector{addr: addr, direct: true}, readPreference: mgo.Primary}
+			defer provider.Close()
+			provider.SetReadPreference(mgo.Nearest)
+			session, err := provider.GetSession()
+			So(err, ShouldBeNil)
+			defer session.Close()
+			So(session.Mode(), ShouldEqual, mgo.Nearest)
+		})
+
+		Convey("a connector that doesn't report direct sessions should keep the primary mode", func() {
+			provider := &SessionProvider{connector: &directConnector{addr: addr}, readPreference: mgo.Primary}
+			defer provider.Close()
+			session, err := provider.GetSession()
+			So(err, ShouldBeNil)
+			defer session.Close()
+			So(session.Mode(), ShouldEqual, mgo.Primary)
+		})
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+package db
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-tools/common/log"
+	"gopkg.in/mgo.v2"
+)
+
+// DefaultStepDownTimeout is how long to wait for a new primary after a step
+// down before giving up, which comfortably covers a routine election.
+const DefaultStepDownTimeout = time.Minute
+
+// stepDownErrors are contained in the errors a node returns for an operation
+// that needs a primary while it isn't one, because it stepped down, is
+// electing a new primary or is recovering. A primary that steps down also
+// closes its connections, which mgo reports as "Closed explicitly" for the
+// operations waiting on them.
+var stepDownErrors = []string{
+	ErrNotMaster,
+	"node is recovering",
+	"interrupted due to repl state change",
+	"primary stepped down",
+	"closed explicitly",
+}
+
+// IsStepDownError returns a boolean indicating if a given error is due to the
+// node no longer being primary, in which case the operation can succeed if
+// retried against the new primary. That includes the connection being closed
+// under the operation, since that's how a primary that steps down drops its
+// clients; if the server went away for good instead, waiting for a new
+// primary times out.
+func IsStepDownError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == io.EOF {
+		return true
+	}
+	lowerCaseError := strings.ToLower(err.Error())
+	for _, stepDownError := range stepDownErrors {
+		if strings.Contains(lowerCaseError, stepDownError) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryOnStepDown runs op, and while it fails with a step down error, waits
+// up to timeout in total for session to find a new primary and runs op again.
+// op must be safe to run more than once.
+func RetryOnStepDown(session *mgo.Session, timeout time.Duration, op func() error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := op()
+		if !IsStepDownError(err) {
+			return err
+		}
+		log.Logvf(log.Always, "primary stepped down (%v), waiting for a new primary", err)
+		if err = waitForPrimary(session, deadline); err != nil {
+			return err
+		}
+		log.Logvf(log.Always, "found a new primary, retrying")
+	}
+}
+
+// waitForPrimary forgets session's connections to the old primary and pings
+// until one to a new primary succeeds or deadline passes.
+func waitForPrimary(session *mgo.Session, deadline time.Time) error {
+	for {
+		session.Refresh()
+		err := session.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no new primary found after the primary stepped down: %v", err)
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+package db
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/mgo.v2"
+)
+
+func TestIsStepDownError(t *testing.T) {
+	Convey("When checking whether an error is due to a step down", t, func() {
+		Convey("errors from a node that isn't primary should be", func() {
+			So(IsStepDownError(errors.New("not master")), ShouldBeTrue)
+			So(IsStepDownError(errors.New("not master and slaveOk=false")), ShouldBeTrue)
+			So(IsStepDownError(errors.New("node is recovering")), ShouldBeTrue)
+			So(IsStepDownError(&mgo.QueryError{Code: 11602, Message: "operation was interrupted because of a replication state change: interrupted due to repl state change"}), ShouldBeTrue)
+		})
+
+		Convey("the connection being closed by a node stepping down should be", func() {
+			So(IsStepDownError(io.EOF), ShouldBeTrue)
+			So(IsStepDownError(errors.New("Closed explicitly")), ShouldBeTrue)
+		})
+
+		Convey("other errors should not be", func() {
+			So(IsStepDownError(nil), ShouldBeFalse)
+			So(IsStepDownError(io.ErrUnexpectedEOF), ShouldBeFalse)
+			So(IsStepDownError(errors.New("E11000 duplicate key error")), ShouldBeFalse)
+		})
+	})
+}
+
+func TestBufferedBulkInserterRetryOnStepDown(t *testing.T) {
+	Convey("When retrying bulk inserts after a step down", t, func() {
+		Convey("an ordered bulk insert should refuse", func() {
+			bufBulk := NewBufferedBulkInserter(&mgo.Collection{}, 3, false)
+			So(bufBulk.RetryOnStepDown(DefaultStepDownTimeout), ShouldNotBeNil)
+		})
+
+		Convey("a bulk insert that continues on error should accept", func() {
+			bufBulk := NewBufferedBulkInserter(&mgo.Collection{}, 3, true)
+			So(bufBulk.RetryOnStepDown(DefaultStepDownTimeout), ShouldBeNil)
+		})
+
+		Convey("an unordered bulk insert should accept", func() {
+			bufBulk := NewBufferedBulkInserter(&mgo.Collection{}, 3, false)
+			bufBulk.Unordered()
+			So(bufBulk.RetryOnStepDown(DefaultStepDownTimeout), ShouldBeNil)
+		})
+	})
+}
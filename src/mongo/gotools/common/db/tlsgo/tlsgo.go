@@ -34,13 +34,107 @@ func (c *TLSDBConnector) Configure(opts options.ToolOptions) error {
 		return fmt.Errorf("FIPS mode not supported")
 	}
 
+	if opts.Auth.Mechanism == "MONGODB-AWS" {
+		return fmt.Errorf("MONGODB-AWS authentication is not supported by this connector")
+	}
+
+	if err := opts.ValidateDriverOptions(); err != nil {
+		return err
+	}
+
 	if opts.SSLCRLFile != "" {
 		return fmt.Errorf("CRL files are not supported on this platform")
 	}
 
+	if opts.SSLCipherList != "" || opts.SSLTLS13Ciphers != "" {
+		return fmt.Errorf("custom cipher lists are not supported on this platform")
+	}
+
+	if opts.SSLDHParamsFile != "" {
+		return fmt.Errorf("DH parameters files are not supported on this platform")
+	}
+
+	if opts.SSLAuditFile != "" {
+		return fmt.Errorf("ssl audit files are not supported on this platform")
+	}
+
+	if opts.SSLStrict {
+		return fmt.Errorf("strict ssl mode is not supported on this platform")
+	}
+
+	if opts.SSLAllowNonCASigner {
+		return fmt.Errorf("relaxing CA basic constraints is not supported on this platform")
+	}
+
+	if opts.SSLIgnoreRootInChain {
+		return fmt.Errorf("ignoring a root in the server's chain is not supported on this platform")
+	}
+
+	if opts.SSLUseSystemCA {
+		return fmt.Errorf("combining the system CAs with a CA file is not supported on this platform")
+	}
+
+	if opts.SSLSignatureAlgorithms != "" {
+		return fmt.Errorf("setting signature algorithms is not supported on this platform")
+	}
+
+	if opts.SSLMinKeyExchangeStrength != 0 {
+		return fmt.Errorf("checking the key exchange group is not supported on this platform")
+	}
+
+	if opts.SSLSkipUnresolvableHosts {
+		return fmt.Errorf("skipping unresolvable hosts is not supported on this platform")
+	}
+
+	if opts.SSLPostHandshakeAuth {
+		return fmt.Errorf("post-handshake authentication is not supported on this platform")
+	}
+
+	if opts.SSLFetchMissingIntermediates {
+		return fmt.Errorf("fetching missing intermediates is not supported on this platform")
+	}
+
+	if opts.SSLConf != "" || opts.SSLProvider != "" {
+		return fmt.Errorf("OpenSSL config files and providers are not supported on this platform")
+	}
+
+	if opts.SSLVerifyAuditOnly {
+		return fmt.Errorf("audit-only verification is not supported on this platform")
+	}
+
+	if opts.SSLRequireSameIssuer {
+		return fmt.Errorf("requiring the same issuer is not supported on this platform")
+	}
+
+	if opts.SSLRequireEMS {
+		return fmt.Errorf("requiring the extended master secret is not supported on this platform")
+	}
+	if opts.SSLMatchServerCiphers {
+		return fmt.Errorf("matching the server's cipher order is not supported on this platform")
+	}
+	if opts.SSLVerifyAKI {
+		return fmt.Errorf("verifying authority key identifiers is not supported on this platform")
+	}
+	if opts.SSLVerifyOCSPChain {
+		return fmt.Errorf("checking certificate revocation over OCSP is not supported on this platform")
+	}
+
+	if opts.SSLTrustOnFirstUse {
+		return fmt.Errorf("trust on first use is not supported on this platform")
+	}
+
+	if len(opts.SSLRequiredPolicyOIDs) > 0 {
+		return fmt.Errorf("required certificate policies are not supported on this platform")
+	}
+
+	// SSLRejectServerRenegotiation needs no handling: crypto/tls clients
+	// refuse renegotiation by default and fail the connection
 	c.config = NewTLSConfig()
 
 	if opts.SSLAllowInvalidCert || opts.SSLAllowInvalidHost {
+		if opts.RequireInsecureAcknowledgment && !opts.SSLAcknowledgeInsecure {
+			return fmt.Errorf("disabling certificate or hostname verification requires acknowledging insecure connections")
+		}
 		c.config.SetInsecure(true)
 	}
 
@@ -76,8 +170,10 @@ func (c *TLSDBConnector) Configure(opts options.ToolOptions) error {
 	} else {
 		c.dialInfo.Addrs = util.CreateConnectionAddrs(opts.Host, opts.Port)
 	}
-	kerberos.AddKerberosOpts(opts, c.dialInfo)
-	return nil
+	if err := opts.Auth.ValidateMechanismProperties(); err != nil {
+		return err
+	}
+	return kerberos.AddKerberosOpts(opts, c.dialInfo)
 }
 
 // GetNewSession dials the server.
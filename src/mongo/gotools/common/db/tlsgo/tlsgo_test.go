@@ -0,0 +1,30 @@
+package tlsgo
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongo-tools/common/options"
+)
+
+func TestConfigureAWSAuth(t *testing.T) {
+	opts := options.ToolOptions{
+		Connection: &options.Connection{Host: "localhost", Port: "27017"},
+		SSL:        &options.SSL{UseSSL: true},
+		Auth:       &options.Auth{Mechanism: "MONGODB-AWS"},
+	}
+	if err := (&TLSDBConnector{}).Configure(opts); err == nil {
+		t.Errorf("Expected MONGODB-AWS authentication to be rejected")
+	}
+}
+
+func TestConfigureDriverOptions(t *testing.T) {
+	opts := options.ToolOptions{
+		Connection:  &options.Connection{Host: "localhost", Port: "27017"},
+		SSL:         &options.SSL{UseSSL: true},
+		Auth:        &options.Auth{},
+		RetryWrites: true,
+	}
+	if err := (&TLSDBConnector{}).Configure(opts); err == nil {
+		t.Errorf("Expected retryable writes to be rejected")
+	}
+}
@@ -8,9 +8,8 @@ package db
 
 import (
 	"github.com/mongodb/mongo-tools/common/connstring"
-	"github.com/mongodb/mongo-tools/common/json"
+	"github.com/mongodb/mongo-tools/common/db/writeconcern"
 	"github.com/mongodb/mongo-tools/common/log"
-	"github.com/mongodb/mongo-tools/common/util"
 	"gopkg.in/mgo.v2"
 
 	"fmt"
@@ -26,74 +25,6 @@ const (
 	wTimeout = "wtimeout"
 )
 
-// constructWCObject takes in a write concern and attempts to construct an
-// mgo.Safe object from it. It returns an error if it is unable to parse the
-// string or if a parsed write concern field value is invalid.
-func constructWCObject(writeConcern string) (sessionSafety *mgo.Safe, err error) {
-	sessionSafety = &mgo.Safe{}
-	defer func() {
-		// If the user passes a w value of 0, we set the session to use the
-		// unacknowledged write concern but only if journal commit acknowledgment,
-		// is not required. If commit acknowledgment is required, it prevails,
-		// and the server will require that mongod acknowledge the write operation
-		if sessionSafety.WMode == "" && sessionSafety.W == 0 && !sessionSafety.J {
-			sessionSafety = nil
-		}
-	}()
-	jsonWriteConcern := map[string]interface{}{}
-
-	if err = json.Unmarshal([]byte(writeConcern), &jsonWriteConcern); err != nil {
-		// if the writeConcern string can not be unmarshaled into JSON, this
-		// allows a default to the old behavior wherein the entire argument
-		// passed in is assigned to the 'w' field - thus allowing users pass
-		// a write concern that looks like: "majority", 0, "4", etc.
-		wValue, err := strconv.Atoi(writeConcern)
-		if err != nil {
-			sessionSafety.WMode = writeConcern
-		} else {
-			sessionSafety.W = wValue
-			if wValue < 0 {
-				return sessionSafety, fmt.Errorf("invalid '%v' argument: %v", w, wValue)
-			}
-		}
-		return sessionSafety, nil
-	}
-
-	if jVal, ok := jsonWriteConcern[j]; ok && util.IsTruthy(jVal) {
-		sessionSafety.J = true
-	}
-
-	if fsyncVal, ok := jsonWriteConcern[fSync]; ok && util.IsTruthy(fsyncVal) {
-		sessionSafety.FSync = true
-	}
-
-	if wtimeout, ok := jsonWriteConcern[wTimeout]; ok {
-		wtimeoutValue, err := util.ToInt(wtimeout)
-		if err != nil {
-			return sessionSafety, fmt.Errorf("invalid '%v' argument: %v", wTimeout, wtimeout)
-		}
-		sessionSafety.WTimeout = wtimeoutValue
-	}
-
-	if wInterface, ok := jsonWriteConcern[w]; ok {
-		wValue, err := util.ToInt(wInterface)
-		if err != nil {
-			// if the argument is neither a string nor int, error out
-			wStrVal, ok := wInterface.(string)
-			if !ok {
-				return sessionSafety, fmt.Errorf("invalid '%v' argument: %v", w, wInterface)
-			}
-			sessionSafety.WMode = wStrVal
-		} else {
-			sessionSafety.W = wValue
-			if wValue < 0 {
-				return sessionSafety, fmt.Errorf("invalid '%v' argument: %v", w, wValue)
-			}
-		}
-	}
-	return sessionSafety, nil
-}
-
 // constructSafetyFromConnString takes in a parsed connection string and attempts
 // to construct an mgo.Safe object from it. It returns an error if it is unable
 // to parse the write concern value.
@@ -144,7 +75,7 @@ func BuildWriteConcern(writeConcern string, nodeType NodeType, cs *connstring.Co
 		if writeConcern == "" {
 			writeConcern = "majority"
 		}
-		sessionSafety, err = constructWCObject(writeConcern)
+		sessionSafety, err = writeconcern.Parse(writeConcern)
 		if err != nil {
 			return nil, err
 		}
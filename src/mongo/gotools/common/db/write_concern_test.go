@@ -99,119 +99,6 @@ func TestBuildWriteConcern(t *testing.T) {
 	})
 }
 
-func TestConstructWCObject(t *testing.T) {
-	Convey("Given a write concern string value, on calling constructWCObject...", t, func() {
-
-		Convey("non-JSON string values should be assigned to the 'WMode' "+
-			"field in their entirety", func() {
-			writeConcernString := "majority"
-			writeConcern, err := constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.WMode, ShouldEqual, writeConcernString)
-		})
-
-		Convey("non-JSON int values should be assigned to the 'w' field "+
-			"in their entirety", func() {
-			writeConcernString := `{w: 4}`
-			writeConcern, err := constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.W, ShouldEqual, 4)
-		})
-
-		Convey("JSON strings with valid j, wtimeout, fsync and w, should be "+
-			"assigned accordingly", func() {
-			writeConcernString := `{w: 3, j: true, fsync: false, wtimeout: 43}`
-			expectedW := 3
-			expectedWTimeout := 43
-			writeConcern, err := constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.W, ShouldEqual, expectedW)
-			So(writeConcern.J, ShouldBeTrue)
-			So(writeConcern.FSync, ShouldBeFalse)
-			So(writeConcern.WTimeout, ShouldEqual, expectedWTimeout)
-		})
-
-		Convey("JSON strings with an argument for j that is not false should set j true", func() {
-			writeConcernString := `{w: 3, j: "rue"}`
-			writeConcern, err := constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.W, ShouldEqual, 3)
-			So(writeConcern.J, ShouldBeTrue)
-		})
-
-		Convey("JSON strings with an argument for fsync that is not false should set fsync true", func() {
-			writeConcernString := `{w: 3, fsync: "rue"}`
-			writeConcern, err := constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.W, ShouldEqual, 3)
-			So(writeConcern.FSync, ShouldBeTrue)
-		})
-
-		Convey("JSON strings with an invalid wtimeout argument should error out", func() {
-			writeConcernString := `{w: 3, wtimeout: "rue"}`
-			_, err := constructWCObject(writeConcernString)
-			So(err, ShouldNotBeNil)
-			writeConcernString = `{w: 3, wtimeout: "43"}`
-			_, err = constructWCObject(writeConcernString)
-			So(err, ShouldNotBeNil)
-		})
-
-		Convey("JSON strings with any non-false j argument should not error out", func() {
-			writeConcernString := `{w: 3, j: "t"}`
-			writeConcern, err := constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.J, ShouldBeTrue)
-			writeConcernString = `{w: 3, j: "f"}`
-			writeConcern, err = constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.J, ShouldBeTrue)
-			writeConcernString = `{w: 3, j: false}`
-			writeConcern, err = constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.J, ShouldBeFalse)
-			writeConcernString = `{w: 3, j: 0}`
-			writeConcern, err = constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.J, ShouldBeFalse)
-		})
-
-		Convey("JSON strings with a shorthand fsync argument should not error out", func() {
-			writeConcernString := `{w: 3, fsync: "t"}`
-			writeConcern, err := constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.FSync, ShouldBeTrue)
-			writeConcernString = `{w: "3", fsync: "f"}`
-			writeConcern, err = constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.FSync, ShouldBeTrue)
-			writeConcernString = `{w: "3", fsync: false}`
-			writeConcern, err = constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.FSync, ShouldBeFalse)
-			writeConcernString = `{w: "3", fsync: 0}`
-			writeConcern, err = constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern.FSync, ShouldBeFalse)
-		})
-
-		Convey("Unacknowledge write concern strings should return a nil object "+
-			"if journaling is not required", func() {
-			writeConcernString := `{w: 0}`
-			writeConcern, err := constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern, ShouldBeNil)
-			writeConcernString = `{w: 0}`
-			writeConcern, err = constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern, ShouldBeNil)
-			writeConcernString = `0`
-			writeConcern, err = constructWCObject(writeConcernString)
-			So(err, ShouldBeNil)
-			So(writeConcern, ShouldBeNil)
-		})
-	})
-}
-
 func TestConstructSafetyFromConnString(t *testing.T) {
 	Convey("Given a parsed &connstring, on calling constructSafetyFromConnString...", t, func() {
 
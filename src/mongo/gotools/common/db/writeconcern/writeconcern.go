@@ -0,0 +1,95 @@
+// Copyright (C) MongoDB, Inc. 2014-present.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+// Package writeconcern parses write concerns given as options, for both the
+// session provider and the connectors, which can't depend on it.
+package writeconcern
+
+import (
+	"github.com/mongodb/mongo-tools/common/json"
+	"github.com/mongodb/mongo-tools/common/util"
+	"gopkg.in/mgo.v2"
+
+	"fmt"
+	"strconv"
+)
+
+// write concern fields
+const (
+	j        = "j"
+	w        = "w"
+	fSync    = "fsync"
+	wTimeout = "wtimeout"
+)
+
+// Parse takes in a write concern and attempts to construct an mgo.Safe object
+// from it. It returns an error if it is unable to parse the string or if a
+// parsed write concern field value is invalid. A nil mgo.Safe means
+// unacknowledged writes.
+func Parse(writeConcern string) (sessionSafety *mgo.Safe, err error) {
+	sessionSafety = &mgo.Safe{}
+	defer func() {
+		// If the user passes a w value of 0, we set the session to use the
+		// unacknowledged write concern but only if journal commit acknowledgment,
+		// is not required. If commit acknowledgment is required, it prevails,
+		// and the server will require that mongod acknowledge the write operation
+		if sessionSafety.WMode == "" && sessionSafety.W == 0 && !sessionSafety.J {
+			sessionSafety = nil
+		}
+	}()
+	jsonWriteConcern := map[string]interface{}{}
+
+	if err = json.Unmarshal([]byte(writeConcern), &jsonWriteConcern); err != nil {
+		// if the writeConcern string can not be unmarshaled into JSON, this
+		// allows a default to the old behavior wherein the entire argument
+		// passed in is assigned to the 'w' field - thus allowing users pass
+		// a write concern that looks like: "majority", 0, "4", etc.
+		wValue, err := strconv.Atoi(writeConcern)
+		if err != nil {
+			sessionSafety.WMode = writeConcern
+		} else {
+			sessionSafety.W = wValue
+			if wValue < 0 {
+				return sessionSafety, fmt.Errorf("invalid '%v' argument: %v", w, wValue)
+			}
+		}
+		return sessionSafety, nil
+	}
+
+	if jVal, ok := jsonWriteConcern[j]; ok && util.IsTruthy(jVal) {
+		sessionSafety.J = true
+	}
+
+	if fsyncVal, ok := jsonWriteConcern[fSync]; ok && util.IsTruthy(fsyncVal) {
+		sessionSafety.FSync = true
+	}
+
+	if wtimeout, ok := jsonWriteConcern[wTimeout]; ok {
+		wtimeoutValue, err := util.ToInt(wtimeout)
+		if err != nil {
+			return sessionSafety, fmt.Errorf("invalid '%v' argument: %v", wTimeout, wtimeout)
+		}
+		sessionSafety.WTimeout = wtimeoutValue
+	}
+
+	if wInterface, ok := jsonWriteConcern[w]; ok {
+		wValue, err := util.ToInt(wInterface)
+		if err != nil {
+			// if the argument is neither a string nor int, error out
+			wStrVal, ok := wInterface.(string)
+			if !ok {
+				return sessionSafety, fmt.Errorf("invalid '%v' argument: %v", w, wInterface)
+			}
+			sessionSafety.WMode = wStrVal
+		} else {
+			sessionSafety.W = wValue
+			if wValue < 0 {
+				return sessionSafety, fmt.Errorf("invalid '%v' argument: %v", w, wValue)
+			}
+		}
+	}
+	return sessionSafety, nil
+}
@@ -31,7 +31,7 @@ var (
 )
 
 var (
-	KnownURIOptionsAuth           = []string{"authsource", "authmechanism"}
+	KnownURIOptionsAuth           = []string{"authsource", "authmechanism", "authmechanismproperties"}
 	KnownURIOptionsConnection     = []string{"connecttimeoutms"}
 	KnownURIOptionsSSL            = []string{"ssl"}
 	KnownURIOptionsReadPreference = []string{"readpreference"}
@@ -75,9 +75,123 @@ type ToolOptions struct {
 	// ReplicaSetName, if specified, will prevent the obtained session from
 	// communicating with any server which is not part of a replica set
 	// with the given name. The default is to communicate with any server
-	// specified or discovered via the servers contacted.
+	// specified or discovered via the servers contacted. If it's set before
+	// the options are parsed, it overrides the replicaSet of an SRV host's
+	// TXT record, and must match one in the query string of the URI.
 	ReplicaSetName string
 
+	// WriteConcern, if specified, is applied to every session returned by the
+	// connector. It accepts either a shorthand w value (e.g. "majority" or "2")
+	// or a document of the form {w: <value>, j: <bool>, fsync: <bool>,
+	// wtimeout: <ms>}, parsed like --writeConcern.
+	WriteConcern string
+
+	// RetryReads and RetryWrites request that the driver transparently retry
+	// eligible operations once after a transient failover. Both default to
+	// off.
+	RetryReads  bool
+	RetryWrites bool
+
+	// MaxStalenessSeconds, if non-zero, excludes secondaries that lag the
+	// primary by more than this many seconds from read selection. The
+	// protocol minimum is 90 seconds.
+	MaxStalenessSeconds int
+
+	// ShareSessions lets connectors built from identical connection and auth
+	// options share a single pool of authenticated connections. The pool's
+	// connections belong to all of them, so they aren't reported to any one
+	// connector's connection event sink, metrics or connection history.
+	ShareSessions bool
+
+	// DisableHeartbeats requests that the driver not monitor the topology in
+	// the background, for short-lived tools that only run a single command.
+	DisableHeartbeats bool
+
+	// ZlibCompressionLevel and ZstdCompressionLevel set the level used by each
+	// wire compressor, from -1 to 9 for zlib and 1 to 22 for zstd. Nil uses
+	// the driver's default.
+	ZlibCompressionLevel *int
+	ZstdCompressionLevel *int
+
+	// ShutdownGrace, if non-zero, is how long closing the session provider
+	// waits for sessions still in use to be closed before closing their
+	// connections.
+	ShutdownGrace time.Duration
+
+	// MongosLoadBalance connects each new session to the next of the seed
+	// mongos in turn, instead of letting them all route through one.
+	MongosLoadBalance bool
+
+	// SelectFastestNode connects each new session directly to every seed in
+	// parallel and keeps the healthy one that connected fastest. As that may
+	// be a secondary, a SessionProvider reads from it in monotonic mode
+	// unless a read preference other than primary is set.
+	SelectFastestNode bool
+
+	// PinToMember, if set, pins each new session to the named replica set
+	// member after discovering the set, so that every operation on it reads
+	// from that member and fails if it becomes unavailable instead of being
+	// routed to another one. A SessionProvider reads from a secondary member
+	// in monotonic mode unless a read preference other than primary is set.
+	PinToMember string
+
+	// RejectArbiter fails getting a session if the connected node is an
+	// arbiter, which holds no data, for tools that read or write data.
+	RejectArbiter bool
+
+	// SRVPollInterval, if set, looks up the SRV record of a mongodb+srv
+	// connection string again at this interval, so that new sessions use the
+	// current seed list.
+	SRVPollInterval time.Duration
+
+	// HealthCheckSelectionTimeout, if set, replaces the connection timeout
+	// for health checks such as Ping and VerifyAllHosts, so that they fail
+	// fast when the deployment is down while operations keep the full
+	// timeout.
+	HealthCheckSelectionTimeout time.Duration
+
+	// SocketLinger, if set, is the SO_LINGER timeout for server connections,
+	// which must be a whole number of seconds. Zero resets connections on
+	// close rather than leaving them in TIME_WAIT. Nil leaves the OS default.
+	SocketLinger *time.Duration
+
+	// SocketReadBuffer and SocketWriteBuffer, if non-zero, are the sizes in
+	// bytes of the receive and send buffers of server connections, which can
+	// limit throughput on links with a high bandwidth-delay product. Zero
+	// leaves the OS default.
+	SocketReadBuffer  int
+	SocketWriteBuffer int
+
+	// MaxMessageSizeBytes, if non-zero, is the largest wire protocol message
+	// accepted from the server. Reading a longer message fails the connection
+	// it arrives on, so the limit can be set below the server's own maximum
+	// to bound the size of any one response.
+	MaxMessageSizeBytes int
+
+	// TotalDialAttempts, if non-zero, limits the connection attempts made
+	// across all seed hosts while establishing a session, including mgo's
+	// retries.
+	TotalDialAttempts int
+
+	// WarmStandby keeps an established session ready in the background, so
+	// that getting a new session doesn't wait for connecting.
+	WarmStandby bool
+
+	// AppKeepAliveInterval, if set, pings the sessions a connector holds on
+	// to, the shared session and the warm standby session, at this interval,
+	// so that they aren't closed for being idle between uses.
+	AppKeepAliveInterval time.Duration
+
+	// RequireInsecureAcknowledgment makes any option that disables or
+	// relaxes certificate or hostname verification an error unless
+	// SSLAcknowledgeInsecure is also set.
+	RequireInsecureAcknowledgment bool
+	SSLAcknowledgeInsecure        bool
+
+	// ConnectionAttemptLogSize, if non-zero, is how many of the most recent
+	// connection attempts the connector remembers for RecentAttempts.
+	ConnectionAttemptLogSize int
+
 	// for caching the parser
 	parser *flags.Parser
 
@@ -138,10 +252,71 @@ type SSL struct {
 	SSLCAFile           string `long:"sslCAFile" value-name:"<filename>" description:"the .pem file containing the root certificate chain from the certificate authority"`
 	SSLPEMKeyFile       string `long:"sslPEMKeyFile" value-name:"<filename>" description:"the .pem file containing the certificate and key"`
 	SSLPEMKeyPassword   string `long:"sslPEMKeyPassword" value-name:"<password>" description:"the password to decrypt the sslPEMKeyFile, if necessary"`
-	SSLCRLFile          string `long:"sslCRLFile" value-name:"<filename>" description:"the .pem file containing the certificate revocation list"`
+	SSLCRLFile          string `long:"sslCRLFile" value-name:"<filename>" description:"the .pem file containing the certificate revocation list, or a glob pattern matching several such files"`
 	SSLAllowInvalidCert bool   `long:"sslAllowInvalidCertificates" description:"bypass the validation for server certificates"`
 	SSLAllowInvalidHost bool   `long:"sslAllowInvalidHostnames" description:"bypass the validation for server name"`
 	SSLFipsMode         bool   `long:"sslFIPSMode" description:"use FIPS mode of the installed openssl library"`
+
+	SSLCipherList                string   `long:"sslCipherList" value-name:"<ciphers>" description:"the OpenSSL cipher list to use for TLS 1.2 and below (defaults to 'HIGH:!EXPORT:!aNULL@STRENGTH')"`
+	SSLAuditFile                 string   `long:"sslAuditFile" value-name:"<filename>" description:"append a JSON record describing the negotiated ssl session of each connection to this file"`
+	SSLStrict                    bool     `long:"sslStrict" description:"reject insecure ssl settings: invalid certificate or hostname bypasses, relaxed CA checks, trust on first use, trusting the system CAs without --sslUseSystemCA, protocols below TLS 1.2, non-AEAD ciphers and compression"`
+	SSLCertExpiryWarning         int      `long:"sslCertExpiryWarning" value-name:"<days>" description:"warn when a server certificate expires within this many days (0 disables the warning)"`
+	SSLAllowNonCASigner          bool     `long:"sslAllowNonCASigner" description:"accept issuing certificates in the server's chain that are not marked as a CA, logging each one, if the chain ends at a root in --sslCAFile; all other checks are still enforced"`
+	SSLIgnoreRootInChain         bool     `long:"sslIgnoreRootInChain" description:"tolerate the server including a self-signed root in its chain that has the same name and key as a root in --sslCAFile"`
+	SSLUseSystemCA               bool     `long:"sslUseSystemCA" description:"trust the system's certificate authorities as well as those in --sslCAFile, which may then contain only intermediate certificates"`
+	SSLSkipUnresolvableHosts     bool     `long:"sslSkipUnresolvableHosts" description:"skip seed hosts whose names don't resolve, with a warning, instead of trying to connect to them"`
+	SSLRequiredPolicyOIDs        []string `long:"sslRequiredPolicyOID" value-name:"<oid>" description:"reject server certificates that don't assert this certificate policy, such as '1.3.6.1.4.1.99999.1'; may be repeated"`
+	SSLTrustOnFirstUse           bool     `long:"sslTrustOnFirstUse" description:"instead of verifying server certificates, trust the key each server presents the first time and record it in --sslKnownHostsFile, rejecting any other key afterwards"`
+	SSLKnownHostsFile            string   `long:"sslKnownHostsFile" value-name:"<filename>" description:"the file of server keys recorded by --sslTrustOnFirstUse"`
+	SSLFetchMissingIntermediates bool     `long:"sslFetchMissingIntermediates" description:"if the server's chain is missing an intermediate certificate, download it from the URL in the certificate's Authority Information Access extension"`
+	SSLVerifyAuditOnly           bool     `long:"sslVerifyAuditOnly" description:"log server certificate and hostname verification failures instead of rejecting the connection; for assessing a rollout only, since connections that fail are NOT secure"`
+	SSLRequireSameIssuer         bool     `long:"sslRequireSameIssuer" description:"reject servers whose certificate is not issued by the same CA as the client certificate in --sslPEMKeyFile"`
+	SSLRequireEMS                bool     `long:"sslRequireEMS" description:"reject TLS 1.2 connections that don't negotiate the extended master secret extension"`
+	SSLMatchServerCiphers        bool     `long:"sslMatchServerCiphers" description:"offer ciphers in the order the server prefers them, learned by probing the first host when connecting"`
+	SSLVerifyAKI                 bool     `long:"sslVerifyAKI" description:"reject servers whose certificate chain doesn't identify the key of a CA in --sslCAFile by authority key identifier; every CA in the file must have a subject key identifier"`
+	SSLVerifyOCSPChain           bool     `long:"sslVerifyOCSPChain" description:"reject servers whose certificate or an intermediate in its chain is reported revoked by its OCSP responder; certificates whose status can't be determined are accepted"`
+
+	// SSLConf and SSLProvider name an OpenSSL config file and provider to
+	// load. Neither can be loaded by this build, which only reads the config
+	// file named by OPENSSL_CONF, so they aren't offered as flags, and setting
+	// either fails with directions to use OPENSSL_CONF instead.
+	SSLConf     string `no-flag:"true"`
+	SSLProvider string `no-flag:"true"`
+
+	// SSLDHParamsFile names a file of DH parameters. They only apply to a
+	// server, which picks the DHE group, so a client can't use them. It isn't
+	// offered as a flag, and setting it fails.
+	SSLDHParamsFile string `no-flag:"true"`
+
+	// SSLTLS13Ciphers is a colon-separated list of TLS 1.3 ciphersuites. The
+	// openssl bindings can't set them apart from the cipher list, so it isn't
+	// offered as a flag; a valid list is rejected as unsupported.
+	SSLTLS13Ciphers string `no-flag:"true"`
+
+	// SSLRejectServerRenegotiation closes connections whose server asks to
+	// renegotiate. The openssl bindings complete renegotiations internally
+	// without a way to observe them, so it can't be honored there and isn't
+	// a flag; Go's TLS clients already refuse renegotiation.
+	SSLRejectServerRenegotiation bool `no-flag:"true"`
+
+	// SSLSignatureAlgorithms is a colon-separated list of the signature
+	// algorithms to advertise, such as 'rsa_pss_rsae_sha256:ECDSA+SHA256'.
+	// Setting the list needs SSL_CTX_set1_sigalgs_list, which the bindings
+	// don't wrap, so this isn't a flag and a valid list fails as unsupported.
+	SSLSignatureAlgorithms string `no-flag:"true"`
+
+	// SSLMinKeyExchangeStrength, in bits of security, would fail connections
+	// whose key exchange group is weaker, such as 128 for P-256 or X25519.
+	// The bindings can't report the negotiated group, so it isn't a flag and
+	// any strength in range is rejected as unsupported.
+	SSLMinKeyExchangeStrength int `no-flag:"true"`
+
+	// SSLPostHandshakeAuth would present the client certificate when the
+	// server asks for it after a TLS 1.3 handshake. Neither connector can
+	// enable that, since the bindings don't wrap
+	// SSL_CTX_set_post_handshake_auth and Go's TLS client doesn't support
+	// it, so it isn't a flag.
+	SSLPostHandshakeAuth bool `no-flag:"true"`
 }
 
 // Struct holding auth-related options
@@ -150,6 +325,10 @@ type Auth struct {
 	Password  string `short:"p" value-name:"<password>" long:"password" description:"password for authentication"`
 	Source    string `long:"authenticationDatabase" value-name:"<database-name>" description:"database that holds the user's credentials"`
 	Mechanism string `long:"authenticationMechanism" value-name:"<mechanism>" description:"authentication mechanism to use"`
+
+	// MechanismProperties holds additional properties of the authentication
+	// mechanism, such as SERVICE_NAME for GSSAPI.
+	MechanismProperties map[string]string `no-flag:"true"`
 }
 
 // Struct for Kerberos/GSSAPI-specific options
@@ -335,14 +514,44 @@ type URISetter interface {
 }
 
 func (auth *Auth) RequiresExternalDB() bool {
-	return auth.Mechanism == "GSSAPI" || auth.Mechanism == "PLAIN" || auth.Mechanism == "MONGODB-X509"
+	return auth.Mechanism == "GSSAPI" || auth.Mechanism == "PLAIN" || auth.Mechanism == "MONGODB-X509" ||
+		auth.Mechanism == "MONGODB-AWS"
+}
+
+// knownMechanismProperties lists the mechanism properties each
+// authentication mechanism accepts. SERVICE_REALM and CANONICALIZE_HOST_NAME
+// aren't accepted for GSSAPI, since mgo has no way to pass them on.
+var knownMechanismProperties = map[string][]string{
+	"GSSAPI":      {"SERVICE_NAME", "SERVICE_HOST"},
+	"MONGODB-AWS": {"AWS_SESSION_TOKEN"},
+}
+
+// ValidateMechanismProperties returns an error if any of the mechanism
+// properties aren't accepted by the authentication mechanism.
+func (auth *Auth) ValidateMechanismProperties() error {
+	if len(auth.MechanismProperties) == 0 {
+		return nil
+	}
+	known, ok := knownMechanismProperties[auth.Mechanism]
+	if !ok {
+		if auth.Mechanism == "" {
+			return fmt.Errorf("mechanism properties require an authentication mechanism")
+		}
+		return fmt.Errorf("authentication mechanism %v does not accept mechanism properties", auth.Mechanism)
+	}
+	for name := range auth.MechanismProperties {
+		if !util.StringSliceContains(known, name) {
+			return fmt.Errorf("unknown mechanism property '%v' for authentication mechanism %v", name, auth.Mechanism)
+		}
+	}
+	return nil
 }
 
 // ShouldAskForPassword returns true if the user specifies a username flag
 // but no password, and the authentication mechanism requires a password.
 func (auth *Auth) ShouldAskForPassword() bool {
 	return auth.Username != "" && auth.Password == "" &&
-		!(auth.Mechanism == "MONGODB-X509" || auth.Mechanism == "GSSAPI")
+		!(auth.Mechanism == "MONGODB-X509" || auth.Mechanism == "GSSAPI" || auth.Mechanism == "MONGODB-AWS")
 }
 
 func (uri *URI) GetConnectionAddrs() []string {
@@ -405,6 +614,55 @@ func (o *ToolOptions) GetAuthenticationDatabase() string {
 	return ""
 }
 
+// minMaxStalenessSeconds is the smallest max staleness allowed by the server
+// selection spec.
+const minMaxStalenessSeconds = 90
+
+// ValidateDriverOptions returns an error if the options request a driver
+// feature that mgo, which every connector is built on, doesn't implement, so
+// that it fails loudly instead of being silently ignored. Each connector
+// checks it when configured.
+func (o *ToolOptions) ValidateDriverOptions() error {
+	// mgo predates logical sessions, which retryable reads and writes are
+	// built on
+	if o.RetryReads || o.RetryWrites {
+		return fmt.Errorf("retryable reads and writes are not supported")
+	}
+	if o.MaxStalenessSeconds != 0 {
+		if o.MaxStalenessSeconds < minMaxStalenessSeconds {
+			return fmt.Errorf("max staleness must be at least %v seconds, got %v",
+				minMaxStalenessSeconds, o.MaxStalenessSeconds)
+		}
+		// mgo's server selection doesn't track secondary lag
+		return fmt.Errorf("max staleness is not supported")
+	}
+	// mgo's ping and topology sync intervals are fixed, so heartbeats can't be
+	// turned off
+	if o.DisableHeartbeats {
+		return fmt.Errorf("disabling heartbeats is not supported")
+	}
+	if o.ZlibCompressionLevel != nil || o.ZstdCompressionLevel != nil {
+		if err := o.validateCompressionLevels(); err != nil {
+			return err
+		}
+		// mgo doesn't implement wire compression
+		return fmt.Errorf("compression levels are not supported")
+	}
+	return nil
+}
+
+// validateCompressionLevels checks that any configured compression levels are
+// within the range their compressor accepts.
+func (o *ToolOptions) validateCompressionLevels() error {
+	if level := o.ZlibCompressionLevel; level != nil && (*level < -1 || *level > 9) {
+		return fmt.Errorf("zlib compression level must be between -1 and 9, got %v", *level)
+	}
+	if level := o.ZstdCompressionLevel; level != nil && (*level < 1 || *level > 22) {
+		return fmt.Errorf("zstd compression level must be between 1 and 22, got %v", *level)
+	}
+	return nil
+}
+
 // AddOptions registers an additional options group to this instance
 func (o *ToolOptions) AddOptions(opts ExtraOptions) {
 	_, err := o.parser.AddGroup(opts.Name()+" options", "", opts)
@@ -490,6 +748,7 @@ func (opts *ToolOptions) setOptionsFromURI(cs connstring.ConnString) error {
 		opts.Password = cs.Password
 		opts.Source = cs.AuthSource
 		opts.Auth.Mechanism = cs.AuthMechanism
+		opts.Auth.MechanismProperties = cs.AuthMechanismProperties
 	}
 	if opts.enabledOptions.Namespace {
 		if opts.Namespace != nil && opts.Namespace.DB != "" {
@@ -499,7 +758,14 @@ func (opts *ToolOptions) setOptionsFromURI(cs connstring.ConnString) error {
 
 	opts.Namespace.DB = cs.Database
 	opts.Direct = (cs.Connect == connstring.SingleConnect)
-	opts.ReplicaSetName = cs.ReplicaSet
+	// a replica set name from the TXT record of the SRV host is only a
+	// default, which one set by the tool overrides; one in the query string
+	// must agree with it
+	if opts.ReplicaSetName == "" {
+		opts.ReplicaSetName = cs.ReplicaSet
+	} else if cs.ReplicaSet != "" && !cs.ReplicaSetFromTXT && cs.ReplicaSet != opts.ReplicaSetName {
+		return fmt.Errorf(ConflictingArgsErrorFormat, "replica set name "+opts.ReplicaSetName)
+	}
 
 	if cs.UseSSL && !BuiltWithSSL {
 		if cs.UsingSRV {
@@ -510,6 +776,10 @@ func (opts *ToolOptions) setOptionsFromURI(cs connstring.ConnString) error {
 	}
 	if cs.UseSSLSeen {
 		if opts.SSL.UseSSL && !cs.UseSSL {
+			if cs.UseSSLFromTXT {
+				return fmt.Errorf("illegal argument combination: --ssl conflicts with ssl=false " +
+					"in the TXT record of the SRV host; set ssl=true in the connection string to override it")
+			}
 			return fmt.Errorf(ConflictingArgsErrorFormat, "--ssl")
 		}
 		opts.SSL.UseSSL = cs.UseSSL
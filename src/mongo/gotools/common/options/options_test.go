@@ -169,6 +169,39 @@ func TestParseAndSetOptions(t *testing.T) {
 				},
 				ShouldError: false,
 			},
+			{
+				Name: "--ssl with ssl=false from the SRV TXT record",
+				CS: connstring.ConnString{
+					UseSSL:        false,
+					UseSSLSeen:    true,
+					UsingSRV:      true,
+					UseSSLFromTXT: true,
+				},
+				WithSSL: true,
+				OptsIn: &ToolOptions{
+					General:        &General{},
+					Verbosity:      &Verbosity{},
+					Connection:     &Connection{},
+					URI:            &URI{},
+					SSL:            &SSL{UseSSL: true},
+					Auth:           &Auth{},
+					Namespace:      &Namespace{},
+					Kerberos:       &Kerberos{},
+					enabledOptions: enabledURIOnly,
+				},
+				OptsExpected: &ToolOptions{
+					General:        &General{},
+					Verbosity:      &Verbosity{},
+					Connection:     &Connection{},
+					URI:            &URI{},
+					SSL:            &SSL{UseSSL: true},
+					Auth:           &Auth{},
+					Namespace:      &Namespace{},
+					Kerberos:       &Kerberos{},
+					enabledOptions: enabledURIOnly,
+				},
+				ShouldError: true,
+			},
 			{
 				Name: "not built with gssapi",
 				CS: connstring.ConnString{
@@ -349,6 +382,105 @@ func TestParseAndSetOptions(t *testing.T) {
 				},
 				ShouldError: false,
 			},
+			{
+				Name: "explicit ReplSetName overrides the SRV TXT record",
+				CS: connstring.ConnString{
+					ReplicaSet:        "fromtxt",
+					UsingSRV:          true,
+					ReplicaSetFromTXT: true,
+				},
+				OptsIn: &ToolOptions{
+					General:        &General{},
+					Verbosity:      &Verbosity{},
+					Connection:     &Connection{},
+					URI:            &URI{},
+					SSL:            &SSL{},
+					Auth:           &Auth{},
+					Namespace:      &Namespace{},
+					Kerberos:       &Kerberos{},
+					enabledOptions: EnabledOptions{URI: true},
+					ReplicaSetName: "explicit",
+				},
+				OptsExpected: &ToolOptions{
+					General:        &General{},
+					Verbosity:      &Verbosity{},
+					Connection:     &Connection{},
+					URI:            &URI{},
+					SSL:            &SSL{},
+					Auth:           &Auth{},
+					Namespace:      &Namespace{},
+					Kerberos:       &Kerberos{},
+					enabledOptions: EnabledOptions{URI: true},
+					ReplicaSetName: "explicit",
+				},
+				ShouldError: false,
+			},
+			{
+				Name: "explicit ReplSetName matching the query string",
+				CS: connstring.ConnString{
+					ReplicaSet:        "explicit",
+					UsingSRV:          true,
+					ReplicaSetFromTXT: false,
+				},
+				OptsIn: &ToolOptions{
+					General:        &General{},
+					Verbosity:      &Verbosity{},
+					Connection:     &Connection{},
+					URI:            &URI{},
+					SSL:            &SSL{},
+					Auth:           &Auth{},
+					Namespace:      &Namespace{},
+					Kerberos:       &Kerberos{},
+					enabledOptions: EnabledOptions{URI: true},
+					ReplicaSetName: "explicit",
+				},
+				OptsExpected: &ToolOptions{
+					General:        &General{},
+					Verbosity:      &Verbosity{},
+					Connection:     &Connection{},
+					URI:            &URI{},
+					SSL:            &SSL{},
+					Auth:           &Auth{},
+					Namespace:      &Namespace{},
+					Kerberos:       &Kerberos{},
+					enabledOptions: EnabledOptions{URI: true},
+					ReplicaSetName: "explicit",
+				},
+				ShouldError: false,
+			},
+			{
+				Name: "explicit ReplSetName conflicting with the query string",
+				CS: connstring.ConnString{
+					ReplicaSet:        "fromquery",
+					UsingSRV:          true,
+					ReplicaSetFromTXT: false,
+				},
+				OptsIn: &ToolOptions{
+					General:        &General{},
+					Verbosity:      &Verbosity{},
+					Connection:     &Connection{},
+					URI:            &URI{},
+					SSL:            &SSL{},
+					Auth:           &Auth{},
+					Namespace:      &Namespace{},
+					Kerberos:       &Kerberos{},
+					enabledOptions: EnabledOptions{URI: true},
+					ReplicaSetName: "explicit",
+				},
+				OptsExpected: &ToolOptions{
+					General:        &General{},
+					Verbosity:      &Verbosity{},
+					Connection:     &Connection{},
+					URI:            &URI{},
+					SSL:            &SSL{},
+					Auth:           &Auth{},
+					Namespace:      &Namespace{},
+					Kerberos:       &Kerberos{},
+					enabledOptions: EnabledOptions{URI: true},
+					ReplicaSetName: "explicit",
+				},
+				ShouldError: true,
+			},
 			{
 				Name: "fail when uri and options set",
 				CS: connstring.ConnString{
@@ -422,3 +554,140 @@ func TestHiddenOptionsDefaults(t *testing.T) {
 	})
 
 }
+
+func TestValidateMechanismProperties(t *testing.T) {
+	Convey("With mechanism properties set", t, func() {
+		testCases := []struct {
+			Mechanism   string
+			Properties  map[string]string
+			ShouldError bool
+		}{
+			{Mechanism: "", Properties: nil, ShouldError: false},
+			{Mechanism: "", Properties: map[string]string{"SERVICE_NAME": "mongodb"}, ShouldError: true},
+			{Mechanism: "SCRAM-SHA-1", Properties: map[string]string{"SERVICE_NAME": "mongodb"}, ShouldError: true},
+			{Mechanism: "GSSAPI", Properties: map[string]string{"SERVICE_NAME": "mongodb", "SERVICE_HOST": "host"}, ShouldError: false},
+			{Mechanism: "GSSAPI", Properties: map[string]string{"SERVICE_REALM": "EXAMPLE.COM"}, ShouldError: true},
+			{Mechanism: "GSSAPI", Properties: map[string]string{"CANONICALIZE_HOST_NAME": "true"}, ShouldError: true},
+			{Mechanism: "MONGODB-AWS", Properties: map[string]string{"AWS_SESSION_TOKEN": "token"}, ShouldError: false},
+			{Mechanism: "MONGODB-AWS", Properties: map[string]string{"SERVICE_NAME": "mongodb"}, ShouldError: true},
+		}
+
+		for _, testCase := range testCases {
+			auth := &Auth{Mechanism: testCase.Mechanism, MechanismProperties: testCase.Properties}
+			err := auth.ValidateMechanismProperties()
+			if testCase.ShouldError {
+				So(err, ShouldNotBeNil)
+			} else {
+				So(err, ShouldBeNil)
+			}
+		}
+	})
+}
+
+func TestUnsupportedSSLFlags(t *testing.T) {
+	Convey("With the ssl options added to a parser", t, func() {
+		opts := New("", "", EnabledOptions{Connection: true})
+		if !BuiltWithSSL {
+			_, err := opts.parser.AddGroup("ssl options", "", opts.SSL)
+			So(err, ShouldBeNil)
+		}
+		So(opts.parser.FindOptionByLongName("sslCAFile"), ShouldNotBeNil)
+
+		Convey("options this build can't apply should not be flags", func() {
+			for _, name := range []string{"sslConf", "sslProvider", "sslDHParamsFile", "sslTLS13Ciphers",
+				"sslRejectServerRenegotiation", "sslSignatureAlgorithms",
+				"sslMinKeyExchangeStrength", "sslPostHandshakeAuth"} {
+				So(opts.parser.FindOptionByLongName(name), ShouldBeNil)
+			}
+		})
+	})
+}
+
+func TestValidateDriverOptions(t *testing.T) {
+	Convey("With ToolOptions set up", t, func() {
+		opts := ToolOptions{}
+		Convey("the defaults should be valid", func() {
+			So(opts.ValidateDriverOptions(), ShouldBeNil)
+		})
+		Convey("retryable reads and writes should be rejected", func() {
+			opts.RetryReads = true
+			So(opts.ValidateDriverOptions(), ShouldNotBeNil)
+			opts.RetryReads = false
+			opts.RetryWrites = true
+			So(opts.ValidateDriverOptions(), ShouldNotBeNil)
+		})
+		Convey("max staleness should be rejected, with an out of range value called out", func() {
+			opts.MaxStalenessSeconds = 30
+			err := opts.ValidateDriverOptions()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "at least 90 seconds")
+			opts.MaxStalenessSeconds = 120
+			err = opts.ValidateDriverOptions()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "not supported")
+		})
+		Convey("disabling heartbeats should be rejected", func() {
+			opts.DisableHeartbeats = true
+			So(opts.ValidateDriverOptions(), ShouldNotBeNil)
+		})
+		Convey("compression levels should be rejected, with an out of range value called out", func() {
+			level := 10
+			opts.ZlibCompressionLevel = &level
+			err := opts.ValidateDriverOptions()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "between -1 and 9")
+			level = 6
+			err = opts.ValidateDriverOptions()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "not supported")
+		})
+	})
+}
+
+func TestValidateCompressionLevels(t *testing.T) {
+	level := func(l int) *int { return &l }
+	cases := []struct {
+		Name  string
+		Zlib  *int
+		Zstd  *int
+		Error string
+	}{
+		{Name: "unset"},
+		{Name: "zlib default", Zlib: level(-1)},
+		{Name: "zlib best", Zlib: level(9)},
+		{Name: "zlib too low", Zlib: level(-2), Error: "zlib compression level"},
+		{Name: "zlib too high", Zlib: level(10), Error: "zlib compression level"},
+		{Name: "zstd fastest", Zstd: level(1)},
+		{Name: "zstd best", Zstd: level(22)},
+		{Name: "zstd too low", Zstd: level(0), Error: "zstd compression level"},
+		{Name: "zstd too high", Zstd: level(23), Error: "zstd compression level"},
+	}
+
+	Convey("Compression levels should be checked against their compressor's range", t, func() {
+		for _, v := range cases {
+			v := v
+			Convey(v.Name, func() {
+				opts := ToolOptions{ZlibCompressionLevel: v.Zlib, ZstdCompressionLevel: v.Zstd}
+				err := opts.validateCompressionLevels()
+				if v.Error == "" {
+					So(err, ShouldBeNil)
+				} else {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, v.Error)
+				}
+			})
+		}
+	})
+}
+
+func TestAWSAuthMechanism(t *testing.T) {
+	Convey("With MONGODB-AWS authentication", t, func() {
+		auth := &Auth{Username: "AKIAEXAMPLE", Mechanism: "MONGODB-AWS"}
+		Convey("the credentials should be checked against $external", func() {
+			So(auth.RequiresExternalDB(), ShouldBeTrue)
+		})
+		Convey("the user should not be asked for a secret key", func() {
+			So(auth.ShouldAskForPassword(), ShouldBeFalse)
+		})
+	})
+}
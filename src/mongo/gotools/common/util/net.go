@@ -28,3 +28,34 @@ func EnableTCPKeepAlive(conn net.Conn, keepAlivePeriod time.Duration) error {
 	}
 	return nil
 }
+
+// SetTCPLinger sets SO_LINGER on the underlying TCP connection, so that
+// closing it blocks for up to linger while unsent data is delivered. A
+// linger of zero discards unsent data and resets the connection instead,
+// which avoids leaving it in TIME_WAIT.
+func SetTCPLinger(conn net.Conn, linger time.Duration) error {
+	if tcpconn, ok := conn.(*net.TCPConn); ok {
+		return tcpconn.SetLinger(int(linger / time.Second))
+	}
+	return nil
+}
+
+// SetTCPBufferSizes sets the receive and send buffer sizes of the underlying
+// TCP connection, leaving either at the OS default if it is zero.
+func SetTCPBufferSizes(conn net.Conn, read, write int) error {
+	tcpconn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+	if read > 0 {
+		if err := tcpconn.SetReadBuffer(read); err != nil {
+			return err
+		}
+	}
+	if write > 0 {
+		if err := tcpconn.SetWriteBuffer(write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -27,6 +27,8 @@ const (
 func main() {
 	// initialize command-line opts
 	opts := options.New("mongodump", mongodump.Usage, options.EnabledOptions{Auth: true, Connection: true, Namespace: true, URI: true})
+	// arbiters hold no data, so a direct connection to one is a mistake
+	opts.RejectArbiter = true
 
 	inputOpts := &mongodump.InputOptions{}
 	opts.AddOptions(inputOpts)
@@ -31,6 +31,8 @@ func main() {
 	// initialize command-line opts
 	opts := options.New("mongoexport", mongoexport.Usage,
 		options.EnabledOptions{Auth: true, Connection: true, Namespace: true, URI: true})
+	// arbiters hold no data, so a direct connection to one is a mistake
+	opts.RejectArbiter = true
 
 	outputOpts := &mongoexport.OutputFormatOptions{}
 	opts.AddOptions(outputOpts)
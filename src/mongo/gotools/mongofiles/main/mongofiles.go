@@ -22,6 +22,8 @@ import (
 func main() {
 	// initialize command-line opts
 	opts := options.New("mongofiles", mongofiles.Usage, options.EnabledOptions{Auth: true, Connection: true, Namespace: false, URI: true})
+	// arbiters hold no data, so a direct connection to one is a mistake
+	opts.RejectArbiter = true
 
 	storageOpts := &mongofiles.StorageOptions{}
 	opts.AddOptions(storageOpts)
@@ -23,6 +23,8 @@ func main() {
 	// initialize command-line opts
 	opts := options.New("mongoimport", mongoimport.Usage,
 		options.EnabledOptions{Auth: true, Connection: true, Namespace: true, URI: true})
+	// arbiters hold no data, so a direct connection to one is a mistake
+	opts.RejectArbiter = true
 
 	inputOpts := &mongoimport.InputOptions{}
 	opts.AddOptions(inputOpts)
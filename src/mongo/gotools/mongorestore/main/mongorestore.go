@@ -30,6 +30,8 @@ func main() {
 	// initialize command-line opts
 	opts := options.New("mongorestore", mongorestore.Usage,
 		options.EnabledOptions{Auth: true, Connection: true, URI: true})
+	// arbiters hold no data, so a direct connection to one is a mistake
+	opts.RejectArbiter = true
 	nsOpts := &mongorestore.NSOptions{}
 	opts.AddOptions(nsOpts)
 	inputOpts := &mongorestore.InputOptions{}
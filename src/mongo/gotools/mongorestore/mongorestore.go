@@ -111,6 +111,10 @@ func (restore *MongoRestore) ParseAndValidateOptions() error {
 	if restore.InputOptions.RestoreDBUsersAndRoles && restore.NSOptions.DB == "admin" {
 		return fmt.Errorf("cannot use --restoreDbUsersAndRoles with the admin database")
 	}
+	if restore.OutputOptions.RetryOnStepDown && restore.OutputOptions.StopOnError {
+		return fmt.Errorf("cannot use --retryOnStepDown with --stopOnError, " +
+			"since retried inserts stop at the documents already inserted")
+	}
 
 	var err error
 	restore.isMongos, err = restore.SessionProvider.IsMongos()
@@ -53,6 +53,10 @@ type OutputOptions struct {
 	TempUsersColl            string `long:"tempUsersColl" default:"tempusers" hidden:"true"`
 	TempRolesColl            string `long:"tempRolesColl" default:"temproles" hidden:"true"`
 	BulkBufferSize           int    `long:"batchSize" default:"1000" hidden:"true"`
+
+	// RetryOnStepDown makes inserts wait for a new primary and retry when
+	// they fail because the primary stepped down.
+	RetryOnStepDown bool `long:"retryOnStepDown" description:"wait for a new primary and retry inserts that fail because the primary stepped down"`
 }
 
 // Name returns a human-readable group name for output options.
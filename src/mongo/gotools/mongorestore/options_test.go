@@ -78,3 +78,28 @@ func TestWriteConcernWithURIParsing(t *testing.T) {
 		})
 	})
 }
+
+func TestRetryOnStepDownParsing(t *testing.T) {
+	Convey("With mongorestore's output options", t, func() {
+		opts := options.New("", "", options.EnabledOptions{Connection: true})
+		outputOpts := &OutputOptions{}
+		opts.AddOptions(outputOpts)
+
+		Convey("--retryOnStepDown should be off by default", func() {
+			_, err := opts.ParseArgs([]string{})
+			So(err, ShouldBeNil)
+			So(outputOpts.RetryOnStepDown, ShouldBeFalse)
+		})
+
+		Convey("--retryOnStepDown should be parsed into them", func() {
+			_, err := opts.ParseArgs([]string{"--retryOnStepDown"})
+			So(err, ShouldBeNil)
+			So(outputOpts.RetryOnStepDown, ShouldBeTrue)
+		})
+	})
+
+	Convey("Tools that don't support it shouldn't offer --retryOnStepDown", t, func() {
+		opts := options.New("", "", options.EnabledOptions{Connection: true})
+		So(opts.FindOptionByLongName("retryOnStepDown"), ShouldBeNil)
+	})
+}
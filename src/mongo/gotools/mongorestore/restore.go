@@ -298,6 +298,12 @@ func (restore *MongoRestore) RestoreCollectionToDB(dbName, colName string,
 			coll := collection.With(s)
 			bulk := db.NewBufferedBulkInserter(
 				coll, restore.OutputOptions.BulkBufferSize, !restore.OutputOptions.StopOnError)
+			if restore.OutputOptions.RetryOnStepDown {
+				if err := bulk.RetryOnStepDown(db.DefaultStepDownTimeout); err != nil {
+					resultChan <- err
+					return
+				}
+			}
 			for rawDoc := range docChan {
 				if restore.objCheck {
 					err := bson.Unmarshal(rawDoc.Data, &bson.D{})